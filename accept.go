@@ -0,0 +1,138 @@
+package epplib
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Errors returned by IPAcceptPolicy.
+var (
+	ErrConnectionNotAllowed    = errors.New("connection not allowed")
+	ErrConnectionRateExceeded  = errors.New("connection rate exceeded")
+	errUnknownRemoteAddrFormat = errors.New("unknown remote address format")
+)
+
+// AcceptPolicy decides if an accepted connection should be served. It is
+// called before the TLS handshake, a returned error closes the connection.
+type AcceptPolicy interface {
+	Accept(conn net.Conn) error
+}
+
+// AcceptPolicyFunc is a function implementing AcceptPolicy.
+type AcceptPolicyFunc func(conn net.Conn) error
+
+// Accept calls f.
+func (f AcceptPolicyFunc) Accept(conn net.Conn) error {
+	return f(conn)
+}
+
+// IPAcceptPolicy accepts connections by remote IP. An IPAcceptPolicy is safe
+// for concurrent use.
+type IPAcceptPolicy struct {
+	// Allow are the networks connections are accepted from, e.g. the
+	// allowlists of all registrars. Connections from everywhere are accepted
+	// if empty.
+	Allow []netip.Prefix
+
+	// Rate is the number of new connections per second accepted from an IP
+	// with bursts of up to Burst connections. A zero Rate means no limit.
+	Rate  float64
+	Burst int
+
+	// now returns the current time, time.Now is used if not set.
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[netip.Addr]*tokenBucket
+	lastSweep time.Time
+}
+
+// Accept accepts the connection if the remote IP is allowed and within the
+// rate.
+func (p *IPAcceptPolicy) Accept(conn net.Conn) error {
+	addr, err := remoteIP(conn.RemoteAddr())
+	if err != nil {
+		return err
+	}
+
+	if len(p.Allow) > 0 && !slices.ContainsFunc(p.Allow, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	}) {
+		return ErrConnectionNotAllowed
+	}
+
+	if p.Rate <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+
+	burst := math.Max(float64(p.Burst), 1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sweep(now, burst)
+
+	bucket, ok := p.buckets[addr]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		p.buckets[addr] = bucket
+	}
+
+	bucket.refill(now, p.Rate, burst)
+
+	if bucket.tokens < 1 {
+		return ErrConnectionRateExceeded
+	}
+
+	bucket.tokens--
+
+	return nil
+}
+
+// sweep removes the buckets that are full again at most once a minute so that
+// IPs that don't connect anymore are forgotten. Must be called with mu held.
+func (p *IPAcceptPolicy) sweep(now time.Time, burst float64) {
+	if p.buckets == nil {
+		p.buckets = make(map[netip.Addr]*tokenBucket)
+		p.lastSweep = now
+	}
+
+	if now.Sub(p.lastSweep) < time.Minute {
+		return
+	}
+
+	p.lastSweep = now
+
+	for addr, bucket := range p.buckets {
+		bucket.refill(now, p.Rate, burst)
+
+		if bucket.tokens >= burst {
+			delete(p.buckets, addr)
+		}
+	}
+}
+
+// remoteIP returns the IP of a remote address without any IPv4 in IPv6
+// mapping.
+func remoteIP(addr net.Addr) (netip.Addr, error) {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.AddrPort().Addr().Unmap(), nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return netip.Addr{}, errUnknownRemoteAddrFormat
+	}
+
+	return addrPort.Addr().Unmap(), nil
+}
@@ -0,0 +1,78 @@
+package epplib
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type remoteAddrConn struct {
+	net.Conn
+
+	remoteAddr net.Addr
+}
+
+func (c *remoteAddrConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func connFrom(addr string) net.Conn {
+	return &remoteAddrConn{remoteAddr: net.TCPAddrFromAddrPort(netip.MustParseAddrPort(addr))}
+}
+
+func TestIPAcceptPolicy_Allow(t *testing.T) {
+	t.Parallel()
+
+	policy := &IPAcceptPolicy{
+		Allow: []netip.Prefix{
+			netip.MustParsePrefix("192.0.2.0/24"),
+			netip.MustParsePrefix("2001:db8::/32"),
+		},
+	}
+
+	for addr, expected := range map[string]error{
+		"192.0.2.10:700":          nil,
+		"[::ffff:192.0.2.10]:700": nil,
+		"[2001:db8::1]:700":       nil,
+		"198.51.100.1:700":        ErrConnectionNotAllowed,
+		"[2001:db9::1]:700":       ErrConnectionNotAllowed,
+	} {
+		assert.ErrorIs(t, policy.Accept(connFrom(addr)), expected, addr)
+	}
+
+	assert.Error(t, policy.Accept(&remoteAddrConn{remoteAddr: &net.UnixAddr{Name: "/tmp/epp.sock"}}))
+}
+
+func TestIPAcceptPolicy_Rate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	policy := &IPAcceptPolicy{
+		Rate:  1,
+		Burst: 2,
+		now:   func() time.Time { return now },
+	}
+
+	require.NoError(t, policy.Accept(connFrom("192.0.2.1:1000")))
+	require.NoError(t, policy.Accept(connFrom("192.0.2.1:1001")))
+	require.ErrorIs(t, policy.Accept(connFrom("192.0.2.1:1002")), ErrConnectionRateExceeded)
+
+	// Other IPs have their own budget.
+	require.NoError(t, policy.Accept(connFrom("192.0.2.2:1000")))
+
+	now = now.Add(time.Second)
+
+	require.NoError(t, policy.Accept(connFrom("192.0.2.1:1003")))
+	require.ErrorIs(t, policy.Accept(connFrom("192.0.2.1:1004")), ErrConnectionRateExceeded)
+
+	// Buckets that are full again are removed.
+	now = now.Add(time.Minute)
+
+	require.NoError(t, policy.Accept(connFrom("192.0.2.3:1000")))
+	assert.Len(t, policy.buckets, 1)
+}
@@ -0,0 +1,158 @@
+package epplib
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// ErrCertificateMismatch is returned by the certificate policies when the
+// client certificate doesn't belong to the client.
+var ErrCertificateMismatch = errors.New("client certificate does not match client id")
+
+// CertificatePolicy verifies that the client certificate of a connection
+// belongs to the client logging in. The CommandMux evaluates it at login after
+// the client has been authenticated, or before a bound login handler if there
+// is no Authenticator. ErrCertificateMismatch, possibly wrapped, counts as a
+// failed login and any other error, e.g. from a failing lookup, results in
+// 2400.
+type CertificatePolicy interface {
+	VerifyCertificate(ctx context.Context, clientID string, state tls.ConnectionState) error
+}
+
+// CertificatePolicyFunc is a function implementing CertificatePolicy.
+type CertificatePolicyFunc func(ctx context.Context, clientID string, state tls.ConnectionState) error
+
+// VerifyCertificate calls f.
+func (f CertificatePolicyFunc) VerifyCertificate(ctx context.Context, clientID string, state tls.ConnectionState) error {
+	return f(ctx, clientID, state)
+}
+
+// CertificateFingerprint returns the SHA-256 fingerprint of the certificate
+// as lower case hex.
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// FingerprintPolicy pins the client certificates by their SHA-256
+// fingerprint. Fingerprints are compared case insensitively and may contain
+// colons, e.g. "AB:CD:...".
+type FingerprintPolicy struct {
+	// Fingerprints returns the fingerprints of the certificates allowed for
+	// the client.
+	Fingerprints func(ctx context.Context, clientID string) ([]string, error)
+}
+
+// VerifyCertificate verifies that the fingerprint of the client certificate is
+// one of the fingerprints of the client.
+func (p *FingerprintPolicy) VerifyCertificate(ctx context.Context, clientID string, state tls.ConnectionState) error {
+	cert, err := clientCertificate(state)
+	if err != nil {
+		return err
+	}
+
+	fingerprints, err := p.Fingerprints(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	fingerprint := CertificateFingerprint(cert)
+
+	for _, allowed := range fingerprints {
+		if strings.EqualFold(strings.ReplaceAll(allowed, ":", ""), fingerprint) {
+			return nil
+		}
+	}
+
+	return ErrCertificateMismatch
+}
+
+// NamePolicy matches the subject common name and DNS names of the client
+// certificate against the names of the client.
+type NamePolicy struct {
+	// Names returns the names allowed for the client.
+	Names func(ctx context.Context, clientID string) ([]string, error)
+}
+
+// VerifyCertificate verifies that the subject common name or one of the DNS
+// names of the client certificate is one of the names of the client.
+func (p *NamePolicy) VerifyCertificate(ctx context.Context, clientID string, state tls.ConnectionState) error {
+	cert, err := clientCertificate(state)
+	if err != nil {
+		return err
+	}
+
+	names, err := p.Names(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	certNames := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+
+	for _, name := range names {
+		if name != "" && slices.ContainsFunc(certNames, func(certName string) bool {
+			return strings.EqualFold(certName, name)
+		}) {
+			return nil
+		}
+	}
+
+	return ErrCertificateMismatch
+}
+
+// CAPolicy requires the client certificate to be issued by one of the CAs of
+// the client.
+type CAPolicy struct {
+	// Roots returns the CAs allowed to issue certificates for the client.
+	Roots func(ctx context.Context, clientID string) (*x509.CertPool, error)
+}
+
+// VerifyCertificate verifies the client certificate chain with the CAs of the
+// client as roots.
+func (p *CAPolicy) VerifyCertificate(ctx context.Context, clientID string, state tls.ConnectionState) error {
+	cert, err := clientCertificate(state)
+	if err != nil {
+		return err
+	}
+
+	roots, err := p.Roots(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	if roots == nil {
+		return ErrCertificateMismatch
+	}
+
+	intermediates := x509.NewCertPool()
+
+	for _, intermediate := range state.PeerCertificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return errors.Join(ErrCertificateMismatch, err)
+	}
+
+	return nil
+}
+
+// clientCertificate returns the client certificate of the connection or
+// ErrCertificateMismatch if there is none.
+func clientCertificate(state tls.ConnectionState) (*x509.Certificate, error) {
+	if len(state.PeerCertificates) == 0 {
+		return nil, ErrCertificateMismatch
+	}
+
+	return state.PeerCertificates[0], nil
+}
@@ -0,0 +1,283 @@
+package epplib
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueCertificate creates a certificate for the template signed by the
+// parent, or self signed if parent is nil.
+func issueCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+func TestCertificatePolicies(t *testing.T) {
+	t.Parallel()
+
+	ca, caKey := issueCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Registrar CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+
+	otherCA, _ := issueCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Other CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+
+	client, _ := issueCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "epp.registrar-x.example"},
+		DNSNames:     []string{"epp2.registrar-x.example"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+
+	pool := func(certs ...*x509.Certificate) *x509.CertPool {
+		p := x509.NewCertPool()
+
+		for _, cert := range certs {
+			p.AddCert(cert)
+		}
+
+		return p
+	}
+
+	colonFingerprint := strings.ToUpper(CertificateFingerprint(client))
+	for i := len(colonFingerprint) - 2; i > 0; i -= 2 {
+		colonFingerprint = colonFingerprint[:i] + ":" + colonFingerprint[i:]
+	}
+
+	for _, tc := range []struct {
+		name        string
+		policy      CertificatePolicy
+		state       tls.ConnectionState
+		expectError bool
+	}{
+		{
+			name: "fingerprint",
+			policy: &FingerprintPolicy{Fingerprints: func(context.Context, string) ([]string, error) {
+				return []string{"00", colonFingerprint}, nil
+			}},
+			state: state,
+		},
+		{
+			name: "wrong fingerprint",
+			policy: &FingerprintPolicy{Fingerprints: func(context.Context, string) ([]string, error) {
+				return []string{CertificateFingerprint(ca)}, nil
+			}},
+			state:       state,
+			expectError: true,
+		},
+		{
+			name: "no certificate",
+			policy: &FingerprintPolicy{Fingerprints: func(context.Context, string) ([]string, error) {
+				return []string{CertificateFingerprint(client)}, nil
+			}},
+			expectError: true,
+		},
+		{
+			name: "subject name",
+			policy: &NamePolicy{Names: func(context.Context, string) ([]string, error) {
+				return []string{"EPP.registrar-x.example"}, nil
+			}},
+			state: state,
+		},
+		{
+			name: "dns name",
+			policy: &NamePolicy{Names: func(context.Context, string) ([]string, error) {
+				return []string{"epp2.registrar-x.example"}, nil
+			}},
+			state: state,
+		},
+		{
+			name: "wrong name",
+			policy: &NamePolicy{Names: func(context.Context, string) ([]string, error) {
+				return []string{"epp.registrar-y.example"}, nil
+			}},
+			state:       state,
+			expectError: true,
+		},
+		{
+			name: "ca",
+			policy: &CAPolicy{Roots: func(context.Context, string) (*x509.CertPool, error) {
+				return pool(ca), nil
+			}},
+			state: state,
+		},
+		{
+			name: "wrong ca",
+			policy: &CAPolicy{Roots: func(context.Context, string) (*x509.CertPool, error) {
+				return pool(otherCA), nil
+			}},
+			state:       state,
+			expectError: true,
+		},
+		{
+			name: "no ca",
+			policy: &CAPolicy{Roots: func(context.Context, string) (*x509.CertPool, error) {
+				return nil, nil
+			}},
+			state:       state,
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.policy.VerifyCertificate(context.Background(), "ClientX", tc.state)
+
+			if tc.expectError {
+				require.ErrorIs(t, err, ErrCertificateMismatch)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+
+	lookupErr := errors.New("lookup failed")
+
+	err := (&NamePolicy{Names: func(context.Context, string) ([]string, error) {
+		return nil, lookupErr
+	}}).VerifyCertificate(context.Background(), "ClientX", state)
+	require.ErrorIs(t, err, lookupErr)
+}
+
+func TestMux_CertificatePolicy(t *testing.T) {
+	t.Parallel()
+
+	cm := &CommandMux{
+		Authenticator: AuthenticatorFunc(func(context.Context, string, string, *x509.Certificate) error {
+			return nil
+		}),
+		CertificatePolicy: CertificatePolicyFunc(func(_ context.Context, clientID string, _ tls.ConnectionState) error {
+			switch clientID {
+			case "ClientX":
+				return nil
+			case "ClientZ":
+				return errors.New("lookup failed")
+			}
+
+			return ErrCertificateMismatch
+		}),
+		MaxLoginAttempts: 2,
+	}
+	cm.BindCommand("info", NamespaceIETFDomain10.String(), func(context.Context, Writer, *etree.Document) {})
+
+	const svcs = `<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>`
+
+	session := &Session{}
+	ctx := ContextWithSession(context.Background(), session)
+
+	for _, step := range []struct {
+		clientID    string
+		expectCode  string
+		expectClose bool
+	}{
+		{clientID: "ClientZ", expectCode: "2400"},
+		{clientID: "ClientY", expectCode: "2200"},
+		{clientID: "ClientY", expectCode: "2501", expectClose: true},
+	} {
+		rw := &ResponseWriter{}
+		cm.Handle(ctx, rw, strings.NewReader(loginCommand(step.clientID, "foo-BAR2", "", "1.0", svcs)))
+
+		doc := etree.NewDocument()
+		require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+		assert.Equal(t, step.expectCode, doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+		assert.Equal(t, step.expectClose, rw.ShouldCloseAfterWrite())
+	}
+
+	assert.False(t, session.LoggedIn())
+
+	session = &Session{}
+	ctx = ContextWithSession(context.Background(), session)
+
+	cm.Handle(ctx, &ResponseWriter{}, strings.NewReader(loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs)))
+	assert.True(t, session.LoggedIn())
+}
+
+func TestMux_CertificatePolicyWithoutAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	var handled bool
+
+	cm := &CommandMux{
+		CertificatePolicy: CertificatePolicyFunc(func(_ context.Context, clientID string, _ tls.ConnectionState) error {
+			if clientID != "ClientX" {
+				return ErrCertificateMismatch
+			}
+
+			return nil
+		}),
+	}
+	cm.Bind(NewXMLPathBuilder().AddOrphan("//command", NamespaceIETFEPP10.String()).Add("login", NamespaceIETFEPP10.String()).String(),
+		func(context.Context, Writer, *etree.Document) {
+			handled = true
+		},
+	)
+
+	const svcs = `<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>`
+
+	ctx := ContextWithSession(context.Background(), &Session{})
+
+	rw := &ResponseWriter{}
+	cm.Handle(ctx, rw, strings.NewReader(loginCommand("ClientY", "foo-BAR2", "", "1.0", svcs)))
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+	assert.Equal(t, "2200", doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+	assert.False(t, handled)
+
+	// A trailing hello must not make the login skip the policy.
+	rw = &ResponseWriter{}
+	cm.Handle(ctx, rw, strings.NewReader(strings.Replace(
+		loginCommand("ClientY", "foo-BAR2", "", "1.0", svcs), "</epp>", "<hello/></epp>", 1,
+	)))
+
+	doc = etree.NewDocument()
+	require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+	assert.Equal(t, "2001", doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+	assert.False(t, handled)
+
+	cm.Handle(ctx, &ResponseWriter{}, strings.NewReader(loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs)))
+	assert.True(t, handled)
+}
@@ -0,0 +1,114 @@
+package epplib
+
+import (
+	"slices"
+
+	"github.com/beevik/etree"
+)
+
+// Commands as described in https://datatracker.ietf.org/doc/html/rfc5730#section-2.9
+var knownCommands = []string{
+	"check",
+	"create",
+	"delete",
+	"info",
+	"login",
+	"logout",
+	"poll",
+	"renew",
+	"transfer",
+	"update",
+}
+
+// CommandInfo describes the command in an EPP document.
+type CommandInfo struct {
+	// Name is the name of the command element, e.g. "check". For hello
+	// documents the name is "hello".
+	Name string
+
+	// Namespace is the namespace of the object the command operates on. It is
+	// empty for commands without an object, e.g. login and poll.
+	Namespace string
+
+	// Objects are the namespaces of all object elements of the command, with
+	// Namespace first.
+	Objects []string
+
+	// Extensions are the namespaces of the elements in the command extension.
+	Extensions []string
+
+	// ClientTransactionID is the clTRID of the command.
+	ClientTransactionID string
+}
+
+// IsKnownCommand reports if the command is one of the commands described in
+// RFC 5730.
+func (ci CommandInfo) IsKnownCommand() bool {
+	return slices.Contains(knownCommands, ci.Name)
+}
+
+// ParseCommandInfo returns information about the command in doc. An empty
+// CommandInfo is returned if doc is not an epp element with exactly one hello
+// or one command, or if the command doesn't have exactly one command element.
+func ParseCommandInfo(doc *etree.Document) CommandInfo {
+	root := doc.Root()
+	if root == nil || !isEPPElement(root, "epp") {
+		return CommandInfo{}
+	}
+
+	children := root.ChildElements()
+	if len(children) != 1 {
+		return CommandInfo{}
+	}
+
+	switch el := children[0]; {
+	case isEPPElement(el, "hello"):
+		return CommandInfo{Name: el.Tag}
+	case isEPPElement(el, "command"):
+		return parseCommandElement(el)
+	default:
+		return CommandInfo{}
+	}
+}
+
+func parseCommandElement(command *etree.Element) CommandInfo {
+	var info CommandInfo
+
+	for _, el := range command.ChildElements() {
+		if el.NamespaceURI() != NamespaceIETFEPP10.String() {
+			return CommandInfo{}
+		}
+
+		switch el.Tag {
+		case "clTRID":
+			info.ClientTransactionID = el.Text()
+		case "extension":
+			for _, ext := range el.ChildElements() {
+				info.Extensions = appendMissing(info.Extensions, ext.NamespaceURI())
+			}
+		default:
+			if info.Name != "" {
+				return CommandInfo{}
+			}
+
+			info.Name = el.Tag
+
+			for _, obj := range el.ChildElements() {
+				if ns := obj.NamespaceURI(); ns != NamespaceIETFEPP10.String() {
+					info.Objects = appendMissing(info.Objects, ns)
+				}
+			}
+
+			if len(info.Objects) > 0 {
+				info.Namespace = info.Objects[0]
+			}
+		}
+	}
+
+	return info
+}
+
+// isEPPElement reports if el is the element with the tag in the EPP namespace.
+func isEPPElement(el *etree.Element, tag string) bool {
+	return el.Tag == tag && el.NamespaceURI() == NamespaceIETFEPP10.String()
+}
@@ -0,0 +1,132 @@
+package epplib
+
+import (
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commandDocument returns a command document with the object element and, if
+// not empty, the elements of the command extension.
+func commandDocument(t *testing.T, command, object, extension string) *etree.Document {
+	t.Helper()
+
+	if extension != "" {
+		extension = `<extension>` + extension + `</extension>`
+	}
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromString(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><`+command+`>`+object+`</`+command+`>`+
+		extension+`<clTRID>ABC-12345</clTRID></command></epp>`))
+
+	return doc
+}
+
+func TestParseCommandInfo(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		document string
+		expect   CommandInfo
+		known    bool
+	}{
+		{
+			name:     "hello",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><hello/></epp>`,
+			expect:   CommandInfo{Name: "hello"},
+		},
+		{
+			name: "command with object and extensions",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command>
+<create><domain:create xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:create></create>
+<extension>
+<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1"/>
+<iis:create xmlns:iis="urn:se:iis:xml:epp:iis-1.2"/>
+</extension>
+<clTRID>ABC-12345</clTRID>
+</command></epp>`,
+			expect: CommandInfo{
+				Name:      "create",
+				Namespace: NamespaceIETFDomain10.String(),
+				Objects:   []string{NamespaceIETFDomain10.String()},
+				Extensions: []string{
+					NamespaceIETFSecDNS11.String(),
+					NamespaceIISEpp12.String(),
+				},
+				ClientTransactionID: "ABC-12345",
+			},
+			known: true,
+		},
+		{
+			name: "command with several objects",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command>
+<info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+<host:info xmlns:host="urn:ietf:params:xml:ns:host-1.0"><host:name>ns1.example.se</host:name></host:info>
+</info>
+</command></epp>`,
+			expect: CommandInfo{
+				Name:      "info",
+				Namespace: NamespaceIETFDomain10.String(),
+				Objects: []string{
+					NamespaceIETFDomain10.String(),
+					NamespaceIETFHost10.String(),
+				},
+			},
+			known: true,
+		},
+		{
+			name: "command without object",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command>
+<login><clID>ClientX</clID><pw>foo-BAR2</pw></login>
+<clTRID>ABC-12345</clTRID>
+</command></epp>`,
+			expect: CommandInfo{
+				Name:                "login",
+				ClientTransactionID: "ABC-12345",
+			},
+			known: true,
+		},
+		{
+			name:     "unknown command",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><foo/></command></epp>`,
+			expect:   CommandInfo{Name: "foo"},
+		},
+		{
+			name:     "not epp",
+			document: `<foo><command><check/></command></foo>`,
+		},
+		{
+			name:     "command in foreign root",
+			document: `<foo><command xmlns="urn:ietf:params:xml:ns:epp-1.0"><check/></command></foo>`,
+		},
+		{
+			name:     "command followed by hello",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><check/></command><hello/></epp>`,
+		},
+		{
+			name:     "two commands",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><check/></command><command><info/></command></epp>`,
+		},
+		{
+			name:     "two command elements",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><check/><info/></command></epp>`,
+		},
+		{
+			name:     "foreign element in command",
+			document: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><check/><foo xmlns="urn:example:foo"/></command></epp>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromString(tc.document))
+
+			info := ParseCommandInfo(doc)
+			assert.Equal(t, tc.expect, info)
+			assert.Equal(t, tc.known, info.IsKnownCommand())
+		})
+	}
+}
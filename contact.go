@@ -0,0 +1,720 @@
+package epplib
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/beevik/etree"
+)
+
+// Contact statuses as described in https://datatracker.ietf.org/doc/html/rfc5733#section-2.2
+var contactStatuses = []string{
+	"clientDeleteProhibited",
+	"clientTransferProhibited",
+	"clientUpdateProhibited",
+	"linked",
+	"ok",
+	"pendingCreate",
+	"pendingDelete",
+	"pendingTransfer",
+	"pendingUpdate",
+	"serverDeleteProhibited",
+	"serverTransferProhibited",
+	"serverUpdateProhibited",
+}
+
+// phoneNumberRegexp matches the e164StringType from RFC 5733.
+var phoneNumberRegexp = regexp.MustCompile(`^\+[0-9]{1,3}\.[0-9]{1,14}$`)
+
+// ContactPostalInfo represent the postalInfo element. Type is "int" or "loc".
+type ContactPostalInfo struct {
+	Type    string
+	Name    string
+	Org     string
+	Address *ContactAddress
+}
+
+// ContactAddress represent the addr element with up to three street lines.
+type ContactAddress struct {
+	Streets []string
+	City    string
+	SP      string
+	PC      string
+	CC      string
+}
+
+// ContactPhone represent the voice and fax elements. Extension is the x
+// attribute.
+type ContactPhone struct {
+	Number    string
+	Extension string
+}
+
+// ContactAuthInfo represent the authInfo element.
+type ContactAuthInfo struct {
+	Password string
+	ROID     string
+}
+
+// ContactDisclose represent the disclose element. Flag tells if the listed
+// elements should be disclosed or not, Names, Orgs and Addrs holds the
+// postalInfo types of the name, org and addr elements.
+type ContactDisclose struct {
+	Flag  bool
+	Names []string
+	Orgs  []string
+	Addrs []string
+	Voice bool
+	Fax   bool
+	Email bool
+}
+
+// ContactCheck represent a contact check command.
+type ContactCheck struct {
+	IDs []string
+}
+
+// ContactInfo represent a contact info command.
+type ContactInfo struct {
+	ID       string
+	AuthInfo *ContactAuthInfo
+}
+
+// ContactCreate represent a contact create command.
+type ContactCreate struct {
+	ID          string
+	PostalInfos []ContactPostalInfo
+	Voice       *ContactPhone
+	Fax         *ContactPhone
+	Email       string
+	AuthInfo    ContactAuthInfo
+	Disclose    *ContactDisclose
+}
+
+// ContactDelete represent a contact delete command.
+type ContactDelete struct {
+	ID string
+}
+
+// ContactTransfer represent a contact transfer command. Op is "request",
+// "query", "approve", "reject" or "cancel".
+type ContactTransfer struct {
+	Op       string
+	ID       string
+	AuthInfo *ContactAuthInfo
+}
+
+// ContactUpdate represent a contact update command.
+type ContactUpdate struct {
+	ID          string
+	AddStatuses []ObjectStatus
+	RemStatuses []ObjectStatus
+	Chg         *ContactUpdateChange
+}
+
+// ContactUpdateChange represent the chg element of a contact update. Fields
+// that are nil are left unchanged. An empty voice or fax number removes it.
+type ContactUpdateChange struct {
+	PostalInfos []ContactPostalInfo
+	Voice       *ContactPhone
+	Fax         *ContactPhone
+	Email       *string
+	AuthInfo    *ContactAuthInfo
+	Disclose    *ContactDisclose
+}
+
+// ParseContactCheck parses a contact check command.
+func ParseContactCheck(doc *etree.Document) (*ContactCheck, error) {
+	el, err := contactCommandElement(doc, "check")
+	if err != nil {
+		return nil, err
+	}
+
+	ids := childTexts(el, "id", NamespaceIETFContact10.String())
+	if len(ids) == 0 {
+		return nil, missingParameterError("id", NamespaceIETFContact10.String())
+	}
+
+	for _, id := range ids {
+		if err := validateContactID(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ContactCheck{IDs: ids}, nil
+}
+
+// ParseContactInfo parses a contact info command.
+func ParseContactInfo(doc *etree.Document) (*ContactInfo, error) {
+	el, err := contactCommandElement(doc, "info")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ContactInfo{
+		AuthInfo: parseContactAuthInfo(el),
+	}
+
+	if info.ID, err = parseContactID(el); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// ParseContactCreate parses a contact create command.
+func ParseContactCreate(doc *etree.Document) (*ContactCreate, error) {
+	el, err := contactCommandElement(doc, "create")
+	if err != nil {
+		return nil, err
+	}
+
+	create := &ContactCreate{}
+
+	if create.ID, err = parseContactID(el); err != nil {
+		return nil, err
+	}
+
+	if create.PostalInfos, err = parseContactPostalInfos(el, true); err != nil {
+		return nil, err
+	}
+
+	if len(create.PostalInfos) == 0 {
+		return nil, missingParameterError("postalInfo", NamespaceIETFContact10.String())
+	}
+
+	if create.Voice, err = parseContactPhone(el, "voice"); err != nil {
+		return nil, err
+	}
+
+	if create.Fax, err = parseContactPhone(el, "fax"); err != nil {
+		return nil, err
+	}
+
+	if create.Email, err = requiredChildText(el, "email", NamespaceIETFContact10.String()); err != nil {
+		return nil, err
+	}
+
+	if err := validateContactEmail(create.Email); err != nil {
+		return nil, err
+	}
+
+	authInfo := parseContactAuthInfo(el)
+	if authInfo == nil {
+		return nil, missingParameterError("authInfo", NamespaceIETFContact10.String())
+	}
+
+	create.AuthInfo = *authInfo
+
+	if create.Disclose, err = parseContactDisclose(el); err != nil {
+		return nil, err
+	}
+
+	return create, nil
+}
+
+// ParseContactDelete parses a contact delete command.
+func ParseContactDelete(doc *etree.Document) (*ContactDelete, error) {
+	el, err := contactCommandElement(doc, "delete")
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseContactID(el)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContactDelete{ID: id}, nil
+}
+
+// ParseContactTransfer parses a contact transfer command.
+func ParseContactTransfer(doc *etree.Document) (*ContactTransfer, error) {
+	el, err := contactCommandElement(doc, "transfer")
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &ContactTransfer{
+		AuthInfo: parseContactAuthInfo(el),
+	}
+
+	if transfer.Op, err = parseTransferOp(el); err != nil {
+		return nil, err
+	}
+
+	if transfer.ID, err = parseContactID(el); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// ParseContactUpdate parses a contact update command.
+func ParseContactUpdate(doc *etree.Document) (*ContactUpdate, error) {
+	el, err := contactCommandElement(doc, "update")
+	if err != nil {
+		return nil, err
+	}
+
+	update := &ContactUpdate{}
+
+	if update.ID, err = parseContactID(el); err != nil {
+		return nil, err
+	}
+
+	if add := childElement(el, "add", NamespaceIETFContact10.String()); add != nil {
+		if update.AddStatuses, err = parseObjectStatuses(add, NamespaceIETFContact10.String(), contactStatuses); err != nil {
+			return nil, err
+		}
+	}
+
+	if rem := childElement(el, "rem", NamespaceIETFContact10.String()); rem != nil {
+		if update.RemStatuses, err = parseObjectStatuses(rem, NamespaceIETFContact10.String(), contactStatuses); err != nil {
+			return nil, err
+		}
+	}
+
+	chg := childElement(el, "chg", NamespaceIETFContact10.String())
+	if chg == nil {
+		return update, nil
+	}
+
+	update.Chg = &ContactUpdateChange{
+		AuthInfo: parseContactAuthInfo(chg),
+	}
+
+	if update.Chg.PostalInfos, err = parseContactPostalInfos(chg, false); err != nil {
+		return nil, err
+	}
+
+	if update.Chg.Voice, err = parseContactPhone(chg, "voice"); err != nil {
+		return nil, err
+	}
+
+	if update.Chg.Fax, err = parseContactPhone(chg, "fax"); err != nil {
+		return nil, err
+	}
+
+	if email := childElement(chg, "email", NamespaceIETFContact10.String()); email != nil {
+		text := strings.TrimSpace(email.Text())
+
+		if err := validateContactEmail(text); err != nil {
+			return nil, err
+		}
+
+		update.Chg.Email = &text
+	}
+
+	if update.Chg.Disclose, err = parseContactDisclose(chg); err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+func contactCommandElement(doc *etree.Document, command string) (*etree.Element, error) {
+	return commandObjectElement(doc, command, NamespaceIETFContact10.String())
+}
+
+func parseContactID(el *etree.Element) (string, error) {
+	id, err := requiredChildText(el, "id", NamespaceIETFContact10.String())
+	if err != nil {
+		return "", err
+	}
+
+	if err := validateContactID(id); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// validateContactID validates the clIDType from RFC 5730.
+func validateContactID(id string) error {
+	if n := len([]rune(id)); n < 3 || n > 16 {
+		return syntaxError("id", NamespaceIETFContact10.String(), id)
+	}
+
+	return nil
+}
+
+func validateContactEmail(email string) error {
+	if local, domain, ok := strings.Cut(email, "@"); !ok || local == "" || domain == "" {
+		return syntaxError("email", NamespaceIETFContact10.String(), email)
+	}
+
+	return nil
+}
+
+// parseContactPostalInfos parses the postalInfo elements. If required is set
+// the name and addr elements must be present, as in a create.
+func parseContactPostalInfos(el *etree.Element, required bool) ([]ContactPostalInfo, error) {
+	var postalInfos []ContactPostalInfo
+
+	for _, postalInfo := range childElements(el, "postalInfo", NamespaceIETFContact10.String()) {
+		info := ContactPostalInfo{
+			Type: postalInfo.SelectAttrValue("type", ""),
+			Name: childText(postalInfo, "name", NamespaceIETFContact10.String()),
+			Org:  childText(postalInfo, "org", NamespaceIETFContact10.String()),
+		}
+
+		if info.Type != "int" && info.Type != "loc" {
+			return nil, syntaxError("postalInfo", NamespaceIETFContact10.String(), info.Type)
+		}
+
+		// At most one "int" and one "loc" postalInfo is allowed.
+		if slices.ContainsFunc(postalInfos, func(p ContactPostalInfo) bool {
+			return p.Type == info.Type
+		}) {
+			return nil, NewError(StatusParameterPolicyError).WithValues(Value{
+				Element:   "postalInfo",
+				Namespace: NamespaceIETFContact10.String(),
+			})
+		}
+
+		if required && info.Name == "" {
+			return nil, missingParameterError("name", NamespaceIETFContact10.String())
+		}
+
+		if addr := childElement(postalInfo, "addr", NamespaceIETFContact10.String()); addr != nil {
+			address, err := parseContactAddress(addr)
+			if err != nil {
+				return nil, err
+			}
+
+			info.Address = address
+		} else if required {
+			return nil, missingParameterError("addr", NamespaceIETFContact10.String())
+		}
+
+		if info.Type == "int" {
+			if err := validateContactInternationalized(info); err != nil {
+				return nil, err
+			}
+		}
+
+		postalInfos = append(postalInfos, info)
+	}
+
+	return postalInfos, nil
+}
+
+func parseContactAddress(el *etree.Element) (*ContactAddress, error) {
+	address := &ContactAddress{
+		Streets: childTexts(el, "street", NamespaceIETFContact10.String()),
+		SP:      childText(el, "sp", NamespaceIETFContact10.String()),
+		PC:      childText(el, "pc", NamespaceIETFContact10.String()),
+	}
+
+	if len(address.Streets) > 3 {
+		return nil, syntaxError("street", NamespaceIETFContact10.String(), address.Streets[3])
+	}
+
+	var err error
+
+	if address.City, err = requiredChildText(el, "city", NamespaceIETFContact10.String()); err != nil {
+		return nil, err
+	}
+
+	if address.CC, err = requiredChildText(el, "cc", NamespaceIETFContact10.String()); err != nil {
+		return nil, err
+	}
+
+	if len(address.CC) != 2 || strings.ContainsFunc(address.CC, func(r rune) bool {
+		return !unicode.IsLetter(r) || r > unicode.MaxASCII
+	}) {
+		return nil, syntaxError("cc", NamespaceIETFContact10.String(), address.CC)
+	}
+
+	return address, nil
+}
+
+// validateContactInternationalized verifies that an "int" postalInfo only
+// uses 7-bit ASCII as required by RFC 5733.
+func validateContactInternationalized(info ContactPostalInfo) error {
+	fields := map[string][]string{
+		"name": {info.Name},
+		"org":  {info.Org},
+	}
+
+	if info.Address != nil {
+		fields["street"] = info.Address.Streets
+		fields["city"] = []string{info.Address.City}
+		fields["sp"] = []string{info.Address.SP}
+		fields["pc"] = []string{info.Address.PC}
+	}
+
+	for _, tag := range []string{"name", "org", "street", "city", "sp", "pc"} {
+		for _, value := range fields[tag] {
+			if strings.ContainsFunc(value, func(r rune) bool { return r > unicode.MaxASCII }) {
+				return syntaxError(tag, NamespaceIETFContact10.String(), value)
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseContactPhone(el *etree.Element, tag string) (*ContactPhone, error) {
+	phone := childElement(el, tag, NamespaceIETFContact10.String())
+	if phone == nil {
+		return nil, nil
+	}
+
+	result := &ContactPhone{
+		Number:    strings.TrimSpace(phone.Text()),
+		Extension: phone.SelectAttrValue("x", ""),
+	}
+
+	if result.Number != "" && !phoneNumberRegexp.MatchString(result.Number) {
+		return nil, syntaxError(tag, NamespaceIETFContact10.String(), result.Number)
+	}
+
+	return result, nil
+}
+
+func parseContactAuthInfo(el *etree.Element) *ContactAuthInfo {
+	authInfo := childElement(el, "authInfo", NamespaceIETFContact10.String())
+	if authInfo == nil {
+		return nil
+	}
+
+	result := &ContactAuthInfo{}
+
+	if pw := childElement(authInfo, "pw", NamespaceIETFContact10.String()); pw != nil {
+		result.Password = pw.Text()
+		result.ROID = pw.SelectAttrValue("roid", "")
+	}
+
+	return result
+}
+
+func parseContactDisclose(el *etree.Element) (*ContactDisclose, error) {
+	disclose := childElement(el, "disclose", NamespaceIETFContact10.String())
+	if disclose == nil {
+		return nil, nil
+	}
+
+	flag, err := ParseXMLBool(disclose.SelectAttrValue("flag", ""))
+	if err != nil {
+		return nil, syntaxError("disclose", NamespaceIETFContact10.String(), disclose.SelectAttrValue("flag", ""))
+	}
+
+	result := &ContactDisclose{
+		Flag:  flag,
+		Voice: childElement(disclose, "voice", NamespaceIETFContact10.String()) != nil,
+		Fax:   childElement(disclose, "fax", NamespaceIETFContact10.String()) != nil,
+		Email: childElement(disclose, "email", NamespaceIETFContact10.String()) != nil,
+	}
+
+	for _, field := range []struct {
+		tag   string
+		types *[]string
+	}{
+		{tag: "name", types: &result.Names},
+		{tag: "org", types: &result.Orgs},
+		{tag: "addr", types: &result.Addrs},
+	} {
+		for _, child := range childElements(disclose, field.tag, NamespaceIETFContact10.String()) {
+			typ := child.SelectAttrValue("type", "")
+			if typ != "int" && typ != "loc" {
+				return nil, syntaxError(field.tag, NamespaceIETFContact10.String(), typ)
+			}
+
+			*field.types = append(*field.types, typ)
+		}
+	}
+
+	return result, nil
+}
+
+// ContactCheckResult represent the cd element of a contact check response.
+type ContactCheckResult struct {
+	ID         string
+	Available  bool
+	Reason     string
+	ReasonLang string
+}
+
+// ContactChkData represent the chkData element of a contact check response.
+type ContactChkData struct {
+	Results []ContactCheckResult
+}
+
+// Element returns the chkData element.
+func (c *ContactChkData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFContact10.String(), "chkData")
+
+	for _, result := range c.Results {
+		createCheckDataChild(el, "id", result.ID, result.Available, result.Reason, result.ReasonLang)
+	}
+
+	return el
+}
+
+// ContactInfData represent the infData element of a contact info response.
+type ContactInfData struct {
+	ID             string
+	ROID           string
+	Statuses       []ObjectStatus
+	PostalInfos    []ContactPostalInfo
+	Voice          *ContactPhone
+	Fax            *ContactPhone
+	Email          string
+	ClientID       string
+	CreateClientID string
+	CreateDate     time.Time
+	UpdateClientID string
+	UpdateDate     time.Time
+	TransferDate   time.Time
+	AuthInfo       *ContactAuthInfo
+	Disclose       *ContactDisclose
+}
+
+// Element returns the infData element.
+func (c *ContactInfData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFContact10.String(), "infData")
+
+	createTextChild(el, "id", c.ID)
+	createTextChild(el, "roid", c.ROID)
+
+	createStatusChildren(el, c.Statuses)
+
+	for _, postalInfo := range c.PostalInfos {
+		createContactPostalInfoChild(el, postalInfo)
+	}
+
+	createContactPhoneChild(el, "voice", c.Voice)
+	createContactPhoneChild(el, "fax", c.Fax)
+	createTextChild(el, "email", c.Email)
+	createTextChild(el, "clID", c.ClientID)
+	createTextChild(el, "crID", c.CreateClientID)
+	createTextChild(el, "crDate", formatDateTime(c.CreateDate))
+	createOptionalTextChild(el, "upID", c.UpdateClientID)
+	createOptionalDateTimeChild(el, "upDate", c.UpdateDate)
+	createOptionalDateTimeChild(el, "trDate", c.TransferDate)
+
+	if c.AuthInfo != nil {
+		authInfo := createChild(el, "authInfo")
+		pw := createTextChild(authInfo, "pw", c.AuthInfo.Password)
+
+		if c.AuthInfo.ROID != "" {
+			pw.CreateAttr("roid", c.AuthInfo.ROID)
+		}
+	}
+
+	if c.Disclose != nil {
+		createContactDiscloseChild(el, c.Disclose)
+	}
+
+	return el
+}
+
+// ContactCreData represent the creData element of a contact create response.
+type ContactCreData struct {
+	ID         string
+	CreateDate time.Time
+}
+
+// Element returns the creData element.
+func (c *ContactCreData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFContact10.String(), "creData")
+
+	createTextChild(el, "id", c.ID)
+	createTextChild(el, "crDate", formatDateTime(c.CreateDate))
+
+	return el
+}
+
+// ContactTrnData represent the trnData element of a contact transfer
+// response.
+type ContactTrnData struct {
+	ID              string
+	TransferStatus  string
+	RequestClientID string
+	RequestDate     time.Time
+	ActionClientID  string
+	ActionDate      time.Time
+}
+
+// Element returns the trnData element.
+func (c *ContactTrnData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFContact10.String(), "trnData")
+
+	createTextChild(el, "id", c.ID)
+	createTextChild(el, "trStatus", c.TransferStatus)
+	createTextChild(el, "reID", c.RequestClientID)
+	createTextChild(el, "reDate", formatDateTime(c.RequestDate))
+	createTextChild(el, "acID", c.ActionClientID)
+	createTextChild(el, "acDate", formatDateTime(c.ActionDate))
+
+	return el
+}
+
+func createContactPostalInfoChild(el *etree.Element, postalInfo ContactPostalInfo) {
+	info := createChild(el, "postalInfo")
+	info.CreateAttr("type", postalInfo.Type)
+
+	createTextChild(info, "name", postalInfo.Name)
+	createOptionalTextChild(info, "org", postalInfo.Org)
+
+	if postalInfo.Address == nil {
+		return
+	}
+
+	addr := createChild(info, "addr")
+
+	for _, street := range postalInfo.Address.Streets {
+		createTextChild(addr, "street", street)
+	}
+
+	createTextChild(addr, "city", postalInfo.Address.City)
+	createOptionalTextChild(addr, "sp", postalInfo.Address.SP)
+	createOptionalTextChild(addr, "pc", postalInfo.Address.PC)
+	createTextChild(addr, "cc", postalInfo.Address.CC)
+}
+
+func createContactPhoneChild(el *etree.Element, tag string, phone *ContactPhone) {
+	if phone == nil {
+		return
+	}
+
+	child := createTextChild(el, tag, phone.Number)
+
+	if phone.Extension != "" {
+		child.CreateAttr("x", phone.Extension)
+	}
+}
+
+func createContactDiscloseChild(el *etree.Element, disclose *ContactDisclose) {
+	d := createChild(el, "disclose")
+	d.CreateAttr("flag", FormatXMLBool(disclose.Flag))
+
+	for _, typ := range disclose.Names {
+		createChild(d, "name").CreateAttr("type", typ)
+	}
+
+	for _, typ := range disclose.Orgs {
+		createChild(d, "org").CreateAttr("type", typ)
+	}
+
+	for _, typ := range disclose.Addrs {
+		createChild(d, "addr").CreateAttr("type", typ)
+	}
+
+	if disclose.Voice {
+		createChild(d, "voice")
+	}
+
+	if disclose.Fax {
+		createChild(d, "fax")
+	}
+
+	if disclose.Email {
+		createChild(d, "email")
+	}
+}
@@ -0,0 +1,267 @@
+package epplib
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContactCreate(t *testing.T) {
+	t.Parallel()
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <create>
+      <contact:create xmlns:contact="urn:ietf:params:xml:ns:contact-1.0">
+        <contact:id>sh8013</contact:id>
+        <contact:postalInfo type="int">
+          <contact:name>John Doe</contact:name>
+          <contact:org>Example Inc.</contact:org>
+          <contact:addr>
+            <contact:street>123 Example Dr.</contact:street>
+            <contact:street>Suite 100</contact:street>
+            <contact:city>Dulles</contact:city>
+            <contact:sp>VA</contact:sp>
+            <contact:pc>20166-6503</contact:pc>
+            <contact:cc>US</contact:cc>
+          </contact:addr>
+        </contact:postalInfo>
+        <contact:voice x="1234">+1.7035555555</contact:voice>
+        <contact:fax>+1.7035555556</contact:fax>
+        <contact:email>jdoe@example.com</contact:email>
+        <contact:authInfo>
+          <contact:pw>2fooBAR</contact:pw>
+        </contact:authInfo>
+        <contact:disclose flag="0">
+          <contact:name type="loc"/>
+          <contact:voice/>
+          <contact:email/>
+        </contact:disclose>
+      </contact:create>
+    </create>
+    <clTRID>ABC-12345</clTRID>
+  </command>
+</epp>`))
+
+	create, err := ParseContactCreate(doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, &ContactCreate{
+		ID: "sh8013",
+		PostalInfos: []ContactPostalInfo{
+			{
+				Type: "int",
+				Name: "John Doe",
+				Org:  "Example Inc.",
+				Address: &ContactAddress{
+					Streets: []string{"123 Example Dr.", "Suite 100"},
+					City:    "Dulles",
+					SP:      "VA",
+					PC:      "20166-6503",
+					CC:      "US",
+				},
+			},
+		},
+		Voice:    &ContactPhone{Number: "+1.7035555555", Extension: "1234"},
+		Fax:      &ContactPhone{Number: "+1.7035555556"},
+		Email:    "jdoe@example.com",
+		AuthInfo: ContactAuthInfo{Password: "2fooBAR"},
+		Disclose: &ContactDisclose{
+			Flag:  false,
+			Names: []string{"loc"},
+			Voice: true,
+			Email: true,
+		},
+	}, create)
+}
+
+func TestParseContactCommandErrors(t *testing.T) {
+	t.Parallel()
+
+	const (
+		postalInfo = `<contact:postalInfo type="loc"><contact:name>Jöhn</contact:name><contact:addr><contact:city>Stockholm</contact:city><contact:cc>SE</contact:cc></contact:addr></contact:postalInfo>`
+		rest       = `<contact:email>jdoe@example.com</contact:email><contact:authInfo><contact:pw>2fooBAR</contact:pw></contact:authInfo>`
+	)
+
+	for _, tc := range []struct {
+		name          string
+		create        string
+		expectedCode  int
+		expectedElem  string
+		expectedValue string
+	}{
+		{
+			name:          "short id",
+			create:        `<contact:id>sh</contact:id>` + postalInfo + rest,
+			expectedCode:  StatusValueSyntaxError,
+			expectedElem:  "id",
+			expectedValue: "sh",
+		},
+		{
+			name:         "missing postalInfo",
+			create:       `<contact:id>sh8013</contact:id>` + rest,
+			expectedCode: StatusMissingParameter,
+			expectedElem: "postalInfo",
+		},
+		{
+			name:         "duplicate postalInfo type",
+			create:       `<contact:id>sh8013</contact:id>` + postalInfo + postalInfo + rest,
+			expectedCode: StatusParameterPolicyError,
+			expectedElem: "postalInfo",
+		},
+		{
+			name:          "non ascii int postalInfo",
+			create:        `<contact:id>sh8013</contact:id><contact:postalInfo type="int"><contact:name>Jöhn</contact:name><contact:addr><contact:city>Stockholm</contact:city><contact:cc>SE</contact:cc></contact:addr></contact:postalInfo>` + rest,
+			expectedCode:  StatusValueSyntaxError,
+			expectedElem:  "name",
+			expectedValue: "Jöhn",
+		},
+		{
+			name:          "too many streets",
+			create:        `<contact:id>sh8013</contact:id><contact:postalInfo type="loc"><contact:name>John</contact:name><contact:addr><contact:street>1</contact:street><contact:street>2</contact:street><contact:street>3</contact:street><contact:street>4</contact:street><contact:city>Stockholm</contact:city><contact:cc>SE</contact:cc></contact:addr></contact:postalInfo>` + rest,
+			expectedCode:  StatusValueSyntaxError,
+			expectedElem:  "street",
+			expectedValue: "4",
+		},
+		{
+			name:          "invalid cc",
+			create:        `<contact:id>sh8013</contact:id><contact:postalInfo type="loc"><contact:name>John</contact:name><contact:addr><contact:city>Stockholm</contact:city><contact:cc>SWE</contact:cc></contact:addr></contact:postalInfo>` + rest,
+			expectedCode:  StatusValueSyntaxError,
+			expectedElem:  "cc",
+			expectedValue: "SWE",
+		},
+		{
+			name:          "invalid voice",
+			create:        `<contact:id>sh8013</contact:id>` + postalInfo + `<contact:voice>070-1234567</contact:voice>` + rest,
+			expectedCode:  StatusValueSyntaxError,
+			expectedElem:  "voice",
+			expectedValue: "070-1234567",
+		},
+		{
+			name:          "invalid email",
+			create:        `<contact:id>sh8013</contact:id>` + postalInfo + `<contact:email>jdoe</contact:email><contact:authInfo><contact:pw>2fooBAR</contact:pw></contact:authInfo>`,
+			expectedCode:  StatusValueSyntaxError,
+			expectedElem:  "email",
+			expectedValue: "jdoe",
+		},
+		{
+			name:          "invalid disclose flag",
+			create:        `<contact:id>sh8013</contact:id>` + postalInfo + rest + `<contact:disclose flag="no"><contact:voice/></contact:disclose>`,
+			expectedCode:  StatusValueSyntaxError,
+			expectedElem:  "disclose",
+			expectedValue: "no",
+		},
+		{
+			name:          "invalid disclose type",
+			create:        `<contact:id>sh8013</contact:id>` + postalInfo + rest + `<contact:disclose flag="1"><contact:addr type="all"/></contact:disclose>`,
+			expectedCode:  StatusValueSyntaxError,
+			expectedElem:  "addr",
+			expectedValue: "all",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromString(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><create><contact:create xmlns:contact="urn:ietf:params:xml:ns:contact-1.0">`+tc.create+`</contact:create></create></command></epp>`))
+
+			_, err := ParseContactCreate(doc)
+
+			var eppErr *EppError
+
+			require.True(t, errors.As(err, &eppErr))
+			assert.Equal(t, tc.expectedCode, eppErr.Code)
+			require.Len(t, eppErr.Values, 1)
+			assert.Equal(t, tc.expectedElem, eppErr.Values[0].Element)
+			assert.Equal(t, tc.expectedValue, eppErr.Values[0].Value)
+			assert.Equal(t, NamespaceIETFContact10.String(), eppErr.Values[0].Namespace)
+		})
+	}
+}
+
+func TestParseContactUpdate(t *testing.T) {
+	t.Parallel()
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromString(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <update>
+      <contact:update xmlns:contact="urn:ietf:params:xml:ns:contact-1.0">
+        <contact:id>sh8013</contact:id>
+        <contact:add>
+          <contact:status s="clientDeleteProhibited"/>
+        </contact:add>
+        <contact:chg>
+          <contact:postalInfo type="int">
+            <contact:org/>
+          </contact:postalInfo>
+          <contact:voice>+1.7034444444</contact:voice>
+          <contact:fax/>
+        </contact:chg>
+      </contact:update>
+    </update>
+  </command>
+</epp>`))
+
+	update, err := ParseContactUpdate(doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, &ContactUpdate{
+		ID:          "sh8013",
+		AddStatuses: []ObjectStatus{{Status: "clientDeleteProhibited"}},
+		Chg: &ContactUpdateChange{
+			PostalInfos: []ContactPostalInfo{{Type: "int"}},
+			Voice:       &ContactPhone{Number: "+1.7034444444"},
+			Fax:         &ContactPhone{},
+		},
+	}, update)
+}
+
+func TestContactInfData(t *testing.T) {
+	t.Parallel()
+
+	data := &ContactInfData{
+		ID:       "sh8013",
+		ROID:     "SH8013-REP",
+		Statuses: []ObjectStatus{{Status: "linked"}},
+		PostalInfos: []ContactPostalInfo{
+			{
+				Type: "int",
+				Name: "John Doe",
+				Address: &ContactAddress{
+					Streets: []string{"123 Example Dr."},
+					City:    "Dulles",
+					CC:      "US",
+				},
+			},
+		},
+		Voice:          &ContactPhone{Number: "+1.7035555555", Extension: "1234"},
+		Email:          "jdoe@example.com",
+		ClientID:       "ClientY",
+		CreateClientID: "ClientX",
+		CreateDate:     time.Date(1999, 4, 3, 22, 0, 0, 0, time.UTC),
+		Disclose:       &ContactDisclose{Flag: false, Voice: true},
+	}
+
+	el := data.Element()
+	assert.Equal(t, NamespaceIETFContact10.String(), el.SelectAttrValue("xmlns:contact", ""))
+
+	var tags []string
+
+	for _, child := range el.ChildElements() {
+		tags = append(tags, child.Tag)
+	}
+
+	assert.Equal(t, []string{"id", "roid", "status", "postalInfo", "voice", "email", "clID", "crID", "crDate", "disclose"}, tags)
+	assert.Equal(t, "1234", el.SelectElement("voice").SelectAttrValue("x", ""))
+	assert.Equal(t, "int", el.SelectElement("postalInfo").SelectAttrValue("type", ""))
+	assert.Equal(t, "Dulles", el.FindElement("postalInfo/addr/city").Text())
+	assert.Equal(t, "false", el.SelectElement("disclose").SelectAttrValue("flag", ""))
+	assert.NotNil(t, el.FindElement("disclose/voice"))
+}
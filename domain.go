@@ -0,0 +1,615 @@
+package epplib
+
+import (
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// Domain statuses as described in https://datatracker.ietf.org/doc/html/rfc5731#section-2.3
+var domainStatuses = []string{
+	"clientDeleteProhibited",
+	"clientHold",
+	"clientRenewProhibited",
+	"clientTransferProhibited",
+	"clientUpdateProhibited",
+	"inactive",
+	"ok",
+	"pendingCreate",
+	"pendingDelete",
+	"pendingRenew",
+	"pendingTransfer",
+	"pendingUpdate",
+	"serverDeleteProhibited",
+	"serverHold",
+	"serverRenewProhibited",
+	"serverTransferProhibited",
+	"serverUpdateProhibited",
+}
+
+// DomainPeriod represent the period element. Unit is "y" or "m".
+type DomainPeriod struct {
+	Value int
+	Unit  string
+}
+
+// DomainAuthInfo represent the authInfo element. Null is set for a
+// <domain:null/> in an update.
+type DomainAuthInfo struct {
+	Password string
+	ROID     string
+	Null     bool
+}
+
+// DomainContact represent the contact element. Type is "admin", "billing" or
+// "tech".
+type DomainContact struct {
+	Type string
+	ID   string
+}
+
+// DomainHostAttr represent the hostAttr element.
+type DomainHostAttr struct {
+	Name  string
+	Addrs []netip.Addr
+}
+
+// DomainNS represent the ns element with either host objects or host
+// attributes.
+type DomainNS struct {
+	HostObjs  []string
+	HostAttrs []DomainHostAttr
+}
+
+// DomainCheck represent a domain check command.
+type DomainCheck struct {
+	Names []string
+}
+
+// DomainInfo represent a domain info command. Hosts is "all", "del", "sub" or
+// "none".
+type DomainInfo struct {
+	Name     string
+	Hosts    string
+	AuthInfo *DomainAuthInfo
+}
+
+// DomainCreate represent a domain create command.
+type DomainCreate struct {
+	Name       string
+	Period     *DomainPeriod
+	NS         DomainNS
+	Registrant string
+	Contacts   []DomainContact
+	AuthInfo   DomainAuthInfo
+}
+
+// DomainDelete represent a domain delete command.
+type DomainDelete struct {
+	Name string
+}
+
+// DomainRenew represent a domain renew command.
+type DomainRenew struct {
+	Name       string
+	CurExpDate time.Time
+	Period     *DomainPeriod
+}
+
+// DomainTransfer represent a domain transfer command. Op is "request",
+// "query", "approve", "reject" or "cancel".
+type DomainTransfer struct {
+	Op       string
+	Name     string
+	Period   *DomainPeriod
+	AuthInfo *DomainAuthInfo
+}
+
+// DomainUpdate represent a domain update command.
+type DomainUpdate struct {
+	Name string
+	Add  DomainUpdateAddRem
+	Rem  DomainUpdateAddRem
+	Chg  *DomainUpdateChange
+}
+
+// DomainUpdateAddRem represent the add and rem elements of a domain update.
+type DomainUpdateAddRem struct {
+	NS       DomainNS
+	Contacts []DomainContact
+	Statuses []ObjectStatus
+}
+
+// DomainUpdateChange represent the chg element of a domain update. An empty
+// Registrant removes the registrant, nil leaves it unchanged.
+type DomainUpdateChange struct {
+	Registrant *string
+	AuthInfo   *DomainAuthInfo
+}
+
+// ParseDomainCheck parses a domain check command.
+func ParseDomainCheck(doc *etree.Document) (*DomainCheck, error) {
+	el, err := domainCommandElement(doc, "check")
+	if err != nil {
+		return nil, err
+	}
+
+	names := childTexts(el, "name", NamespaceIETFDomain10.String())
+	if len(names) == 0 || slices.Contains(names, "") {
+		return nil, missingParameterError("name", NamespaceIETFDomain10.String())
+	}
+
+	return &DomainCheck{Names: names}, nil
+}
+
+// ParseDomainInfo parses a domain info command.
+func ParseDomainInfo(doc *etree.Document) (*DomainInfo, error) {
+	el, err := domainCommandElement(doc, "info")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DomainInfo{
+		AuthInfo: parseDomainAuthInfo(el),
+	}
+
+	nameEl := childElement(el, "name", NamespaceIETFDomain10.String())
+	if nameEl == nil || strings.TrimSpace(nameEl.Text()) == "" {
+		return nil, missingParameterError("name", NamespaceIETFDomain10.String())
+	}
+
+	info.Name = strings.TrimSpace(nameEl.Text())
+	info.Hosts = nameEl.SelectAttrValue("hosts", "all")
+
+	if !slices.Contains([]string{"all", "del", "sub", "none"}, info.Hosts) {
+		return nil, syntaxError("name", NamespaceIETFDomain10.String(), info.Hosts)
+	}
+
+	return info, nil
+}
+
+// ParseDomainCreate parses a domain create command.
+func ParseDomainCreate(doc *etree.Document) (*DomainCreate, error) {
+	el, err := domainCommandElement(doc, "create")
+	if err != nil {
+		return nil, err
+	}
+
+	create := &DomainCreate{
+		Registrant: childText(el, "registrant", NamespaceIETFDomain10.String()),
+	}
+
+	if create.Name, err = requiredChildText(el, "name", NamespaceIETFDomain10.String()); err != nil {
+		return nil, err
+	}
+
+	if create.Period, err = parseDomainPeriod(el); err != nil {
+		return nil, err
+	}
+
+	if create.NS, err = parseDomainNS(el); err != nil {
+		return nil, err
+	}
+
+	if create.Contacts, err = parseDomainContacts(el); err != nil {
+		return nil, err
+	}
+
+	authInfo := parseDomainAuthInfo(el)
+	if authInfo == nil {
+		return nil, missingParameterError("authInfo", NamespaceIETFDomain10.String())
+	}
+
+	create.AuthInfo = *authInfo
+
+	return create, nil
+}
+
+// ParseDomainDelete parses a domain delete command.
+func ParseDomainDelete(doc *etree.Document) (*DomainDelete, error) {
+	el, err := domainCommandElement(doc, "delete")
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := requiredChildText(el, "name", NamespaceIETFDomain10.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &DomainDelete{Name: name}, nil
+}
+
+// ParseDomainRenew parses a domain renew command.
+func ParseDomainRenew(doc *etree.Document) (*DomainRenew, error) {
+	el, err := domainCommandElement(doc, "renew")
+	if err != nil {
+		return nil, err
+	}
+
+	renew := &DomainRenew{}
+
+	if renew.Name, err = requiredChildText(el, "name", NamespaceIETFDomain10.String()); err != nil {
+		return nil, err
+	}
+
+	curExpDate, err := requiredChildText(el, "curExpDate", NamespaceIETFDomain10.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if renew.CurExpDate, err = time.Parse(time.DateOnly, curExpDate); err != nil {
+		return nil, syntaxError("curExpDate", NamespaceIETFDomain10.String(), curExpDate)
+	}
+
+	if renew.Period, err = parseDomainPeriod(el); err != nil {
+		return nil, err
+	}
+
+	return renew, nil
+}
+
+// ParseDomainTransfer parses a domain transfer command.
+func ParseDomainTransfer(doc *etree.Document) (*DomainTransfer, error) {
+	el, err := domainCommandElement(doc, "transfer")
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &DomainTransfer{
+		AuthInfo: parseDomainAuthInfo(el),
+	}
+
+	if transfer.Op, err = parseTransferOp(el); err != nil {
+		return nil, err
+	}
+
+	if transfer.Name, err = requiredChildText(el, "name", NamespaceIETFDomain10.String()); err != nil {
+		return nil, err
+	}
+
+	if transfer.Period, err = parseDomainPeriod(el); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// ParseDomainUpdate parses a domain update command.
+func ParseDomainUpdate(doc *etree.Document) (*DomainUpdate, error) {
+	el, err := domainCommandElement(doc, "update")
+	if err != nil {
+		return nil, err
+	}
+
+	update := &DomainUpdate{}
+
+	if update.Name, err = requiredChildText(el, "name", NamespaceIETFDomain10.String()); err != nil {
+		return nil, err
+	}
+
+	if add := childElement(el, "add", NamespaceIETFDomain10.String()); add != nil {
+		if update.Add, err = parseDomainUpdateAddRem(add); err != nil {
+			return nil, err
+		}
+	}
+
+	if rem := childElement(el, "rem", NamespaceIETFDomain10.String()); rem != nil {
+		if update.Rem, err = parseDomainUpdateAddRem(rem); err != nil {
+			return nil, err
+		}
+	}
+
+	if chg := childElement(el, "chg", NamespaceIETFDomain10.String()); chg != nil {
+		update.Chg = &DomainUpdateChange{
+			AuthInfo: parseDomainAuthInfo(chg),
+		}
+
+		if registrant := childElement(chg, "registrant", NamespaceIETFDomain10.String()); registrant != nil {
+			text := strings.TrimSpace(registrant.Text())
+			update.Chg.Registrant = &text
+		}
+	}
+
+	return update, nil
+}
+
+func domainCommandElement(doc *etree.Document, command string) (*etree.Element, error) {
+	return commandObjectElement(doc, command, NamespaceIETFDomain10.String())
+}
+
+func parseDomainPeriod(el *etree.Element) (*DomainPeriod, error) {
+	period := childElement(el, "period", NamespaceIETFDomain10.String())
+	if period == nil {
+		return nil, nil
+	}
+
+	text := strings.TrimSpace(period.Text())
+
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return nil, syntaxError("period", NamespaceIETFDomain10.String(), text)
+	}
+
+	if value < 1 || value > 99 {
+		return nil, rangeError("period", NamespaceIETFDomain10.String(), text)
+	}
+
+	unit := period.SelectAttrValue("unit", "")
+	if unit != "y" && unit != "m" {
+		return nil, syntaxError("period", NamespaceIETFDomain10.String(), text)
+	}
+
+	return &DomainPeriod{Value: value, Unit: unit}, nil
+}
+
+func parseDomainNS(el *etree.Element) (DomainNS, error) {
+	var result DomainNS
+
+	ns := childElement(el, "ns", NamespaceIETFDomain10.String())
+	if ns == nil {
+		return result, nil
+	}
+
+	result.HostObjs = childTexts(ns, "hostObj", NamespaceIETFDomain10.String())
+
+	for _, hostAttr := range childElements(ns, "hostAttr", NamespaceIETFDomain10.String()) {
+		name, err := requiredChildText(hostAttr, "hostName", NamespaceIETFDomain10.String())
+		if err != nil {
+			return result, err
+		}
+
+		attr := DomainHostAttr{Name: name}
+
+		for _, addrEl := range childElements(hostAttr, "hostAddr", NamespaceIETFDomain10.String()) {
+			addr, err := parseIPAddress(addrEl, NamespaceIETFDomain10.String())
+			if err != nil {
+				return result, err
+			}
+
+			attr.Addrs = append(attr.Addrs, addr)
+		}
+
+		result.HostAttrs = append(result.HostAttrs, attr)
+	}
+
+	if len(result.HostObjs) > 0 && len(result.HostAttrs) > 0 {
+		return result, NewError(StatusParameterPolicyError).WithValues(Value{
+			Element:   "ns",
+			Namespace: NamespaceIETFDomain10.String(),
+		})
+	}
+
+	return result, nil
+}
+
+func parseDomainContacts(el *etree.Element) ([]DomainContact, error) {
+	var contacts []DomainContact
+
+	for _, contact := range childElements(el, "contact", NamespaceIETFDomain10.String()) {
+		c := DomainContact{
+			Type: contact.SelectAttrValue("type", ""),
+			ID:   strings.TrimSpace(contact.Text()),
+		}
+
+		if !slices.Contains([]string{"admin", "billing", "tech"}, c.Type) {
+			return nil, syntaxError("contact", NamespaceIETFDomain10.String(), c.ID)
+		}
+
+		contacts = append(contacts, c)
+	}
+
+	return contacts, nil
+}
+
+func parseDomainAuthInfo(el *etree.Element) *DomainAuthInfo {
+	authInfo := childElement(el, "authInfo", NamespaceIETFDomain10.String())
+	if authInfo == nil {
+		return nil
+	}
+
+	if childElement(authInfo, "null", NamespaceIETFDomain10.String()) != nil {
+		return &DomainAuthInfo{Null: true}
+	}
+
+	result := &DomainAuthInfo{}
+
+	if pw := childElement(authInfo, "pw", NamespaceIETFDomain10.String()); pw != nil {
+		result.Password = pw.Text()
+		result.ROID = pw.SelectAttrValue("roid", "")
+	}
+
+	return result
+}
+
+func parseDomainUpdateAddRem(el *etree.Element) (DomainUpdateAddRem, error) {
+	var (
+		result DomainUpdateAddRem
+		err    error
+	)
+
+	if result.NS, err = parseDomainNS(el); err != nil {
+		return result, err
+	}
+
+	if result.Contacts, err = parseDomainContacts(el); err != nil {
+		return result, err
+	}
+
+	if result.Statuses, err = parseObjectStatuses(el, NamespaceIETFDomain10.String(), domainStatuses); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// DomainCheckResult represent the cd element of a domain check response.
+type DomainCheckResult struct {
+	Name       string
+	Available  bool
+	Reason     string
+	ReasonLang string
+}
+
+// DomainChkData represent the chkData element of a domain check response.
+type DomainChkData struct {
+	Results []DomainCheckResult
+}
+
+// Element returns the chkData element.
+func (d *DomainChkData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFDomain10.String(), "chkData")
+
+	for _, result := range d.Results {
+		createCheckDataChild(el, "name", result.Name, result.Available, result.Reason, result.ReasonLang)
+	}
+
+	return el
+}
+
+// DomainInfData represent the infData element of a domain info response.
+type DomainInfData struct {
+	Name           string
+	ROID           string
+	Statuses       []ObjectStatus
+	Registrant     string
+	Contacts       []DomainContact
+	NS             DomainNS
+	Hosts          []string
+	ClientID       string
+	CreateClientID string
+	CreateDate     time.Time
+	UpdateClientID string
+	UpdateDate     time.Time
+	ExpireDate     time.Time
+	TransferDate   time.Time
+	AuthInfo       *DomainAuthInfo
+}
+
+// Element returns the infData element.
+func (d *DomainInfData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFDomain10.String(), "infData")
+
+	createTextChild(el, "name", d.Name)
+	createTextChild(el, "roid", d.ROID)
+
+	createStatusChildren(el, d.Statuses)
+
+	createOptionalTextChild(el, "registrant", d.Registrant)
+
+	for _, contact := range d.Contacts {
+		createTextChild(el, "contact", contact.ID).CreateAttr("type", contact.Type)
+	}
+
+	createDomainNSChild(el, d.NS)
+
+	for _, host := range d.Hosts {
+		createTextChild(el, "host", host)
+	}
+
+	createTextChild(el, "clID", d.ClientID)
+	createOptionalTextChild(el, "crID", d.CreateClientID)
+	createOptionalDateTimeChild(el, "crDate", d.CreateDate)
+	createOptionalTextChild(el, "upID", d.UpdateClientID)
+	createOptionalDateTimeChild(el, "upDate", d.UpdateDate)
+	createOptionalDateTimeChild(el, "exDate", d.ExpireDate)
+	createOptionalDateTimeChild(el, "trDate", d.TransferDate)
+
+	if d.AuthInfo != nil {
+		authInfo := createChild(el, "authInfo")
+		pw := createTextChild(authInfo, "pw", d.AuthInfo.Password)
+
+		if d.AuthInfo.ROID != "" {
+			pw.CreateAttr("roid", d.AuthInfo.ROID)
+		}
+	}
+
+	return el
+}
+
+// DomainCreData represent the creData element of a domain create response.
+type DomainCreData struct {
+	Name       string
+	CreateDate time.Time
+	ExpireDate time.Time
+}
+
+// Element returns the creData element.
+func (d *DomainCreData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFDomain10.String(), "creData")
+
+	createTextChild(el, "name", d.Name)
+	createTextChild(el, "crDate", formatDateTime(d.CreateDate))
+	createOptionalDateTimeChild(el, "exDate", d.ExpireDate)
+
+	return el
+}
+
+// DomainRenData represent the renData element of a domain renew response.
+type DomainRenData struct {
+	Name       string
+	ExpireDate time.Time
+}
+
+// Element returns the renData element.
+func (d *DomainRenData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFDomain10.String(), "renData")
+
+	createTextChild(el, "name", d.Name)
+	createOptionalDateTimeChild(el, "exDate", d.ExpireDate)
+
+	return el
+}
+
+// DomainTrnData represent the trnData element of a domain transfer response.
+// TransferStatus is "clientApproved", "clientCancelled", "clientRejected",
+// "pending", "serverApproved" or "serverCancelled".
+type DomainTrnData struct {
+	Name            string
+	TransferStatus  string
+	RequestClientID string
+	RequestDate     time.Time
+	ActionClientID  string
+	ActionDate      time.Time
+	ExpireDate      time.Time
+}
+
+// Element returns the trnData element.
+func (d *DomainTrnData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFDomain10.String(), "trnData")
+
+	createTextChild(el, "name", d.Name)
+	createTextChild(el, "trStatus", d.TransferStatus)
+	createTextChild(el, "reID", d.RequestClientID)
+	createTextChild(el, "reDate", formatDateTime(d.RequestDate))
+	createTextChild(el, "acID", d.ActionClientID)
+	createTextChild(el, "acDate", formatDateTime(d.ActionDate))
+	createOptionalDateTimeChild(el, "exDate", d.ExpireDate)
+
+	return el
+}
+
+func createDomainNSChild(el *etree.Element, ns DomainNS) {
+	if len(ns.HostObjs) == 0 && len(ns.HostAttrs) == 0 {
+		return
+	}
+
+	nsEl := createChild(el, "ns")
+
+	for _, hostObj := range ns.HostObjs {
+		createTextChild(nsEl, "hostObj", hostObj)
+	}
+
+	for _, hostAttr := range ns.HostAttrs {
+		attr := createChild(nsEl, "hostAttr")
+		createTextChild(attr, "hostName", hostAttr.Name)
+
+		for _, addr := range hostAttr.Addrs {
+			createIPAddressChild(attr, "hostAddr", addr)
+		}
+	}
+}
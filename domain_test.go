@@ -0,0 +1,267 @@
+package epplib
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDomainCreate(t *testing.T) {
+	t.Parallel()
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <create>
+      <domain:create xmlns:domain="urn:ietf:params:xml:ns:domain-1.0">
+        <domain:name>example.se</domain:name>
+        <domain:period unit="y">2</domain:period>
+        <domain:ns>
+          <domain:hostAttr>
+            <domain:hostName>ns1.example.se</domain:hostName>
+            <domain:hostAddr ip="v4">192.0.2.2</domain:hostAddr>
+            <domain:hostAddr ip="v6">2001:db8::1</domain:hostAddr>
+          </domain:hostAttr>
+        </domain:ns>
+        <domain:registrant>jd1234</domain:registrant>
+        <domain:contact type="admin">sh8013</domain:contact>
+        <domain:contact type="tech">sh8013</domain:contact>
+        <domain:authInfo>
+          <domain:pw>2fooBAR</domain:pw>
+        </domain:authInfo>
+      </domain:create>
+    </create>
+    <clTRID>ABC-12345</clTRID>
+  </command>
+</epp>`))
+
+	create, err := ParseDomainCreate(doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, &DomainCreate{
+		Name:   "example.se",
+		Period: &DomainPeriod{Value: 2, Unit: "y"},
+		NS: DomainNS{
+			HostAttrs: []DomainHostAttr{
+				{
+					Name: "ns1.example.se",
+					Addrs: []netip.Addr{
+						netip.MustParseAddr("192.0.2.2"),
+						netip.MustParseAddr("2001:db8::1"),
+					},
+				},
+			},
+		},
+		Registrant: "jd1234",
+		Contacts: []DomainContact{
+			{Type: "admin", ID: "sh8013"},
+			{Type: "tech", ID: "sh8013"},
+		},
+		AuthInfo: DomainAuthInfo{Password: "2fooBAR"},
+	}, create)
+}
+
+func TestParseDomainCommandErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name         string
+		command      string
+		parse        func(*etree.Document) error
+		expectedCode int
+		expectedElem string
+	}{
+		{
+			name:         "wrong command",
+			command:      `<info><domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info></info>`,
+			parse:        func(doc *etree.Document) error { _, err := ParseDomainCheck(doc); return err },
+			expectedCode: StatusCommandSyntaxError,
+		},
+		{
+			name:         "missing name",
+			command:      `<delete><domain:delete xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"/></delete>`,
+			parse:        func(doc *etree.Document) error { _, err := ParseDomainDelete(doc); return err },
+			expectedCode: StatusMissingParameter,
+			expectedElem: "name",
+		},
+		{
+			name:         "invalid hosts",
+			command:      `<info><domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name hosts="some">example.se</domain:name></domain:info></info>`,
+			parse:        func(doc *etree.Document) error { _, err := ParseDomainInfo(doc); return err },
+			expectedCode: StatusValueSyntaxError,
+			expectedElem: "name",
+		},
+		{
+			name:         "period out of range",
+			command:      `<renew><domain:renew xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name><domain:curExpDate>2000-04-03</domain:curExpDate><domain:period unit="y">100</domain:period></domain:renew></renew>`,
+			parse:        func(doc *etree.Document) error { _, err := ParseDomainRenew(doc); return err },
+			expectedCode: StatusValueRangeError,
+			expectedElem: "period",
+		},
+		{
+			name:         "invalid curExpDate",
+			command:      `<renew><domain:renew xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name><domain:curExpDate>2000-04-03T00:00:00Z</domain:curExpDate></domain:renew></renew>`,
+			parse:        func(doc *etree.Document) error { _, err := ParseDomainRenew(doc); return err },
+			expectedCode: StatusValueSyntaxError,
+			expectedElem: "curExpDate",
+		},
+		{
+			name:         "address family mismatch",
+			command:      `<update><domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name><domain:add><domain:ns><domain:hostAttr><domain:hostName>ns1.example.se</domain:hostName><domain:hostAddr ip="v4">2001:db8::1</domain:hostAddr></domain:hostAttr></domain:ns></domain:add></domain:update></update>`,
+			parse:        func(doc *etree.Document) error { _, err := ParseDomainUpdate(doc); return err },
+			expectedCode: StatusValueSyntaxError,
+			expectedElem: "hostAddr",
+		},
+		{
+			name:         "invalid status",
+			command:      `<update><domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name><domain:rem><domain:status s="locked"/></domain:rem></domain:update></update>`,
+			parse:        func(doc *etree.Document) error { _, err := ParseDomainUpdate(doc); return err },
+			expectedCode: StatusValueSyntaxError,
+			expectedElem: "status",
+		},
+		{
+			name:         "invalid transfer op",
+			command:      `<transfer op="steal"><domain:transfer xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:transfer></transfer>`,
+			parse:        func(doc *etree.Document) error { _, err := ParseDomainTransfer(doc); return err },
+			expectedCode: StatusValueSyntaxError,
+			expectedElem: "transfer",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromString(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command>`+tc.command+`</command></epp>`))
+
+			var eppErr *EppError
+
+			require.True(t, errors.As(tc.parse(doc), &eppErr))
+			assert.Equal(t, tc.expectedCode, eppErr.Code)
+
+			if tc.expectedElem != "" {
+				require.Len(t, eppErr.Values, 1)
+				assert.Equal(t, tc.expectedElem, eppErr.Values[0].Element)
+			}
+		})
+	}
+}
+
+func TestParseDomainUpdate(t *testing.T) {
+	t.Parallel()
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromString(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <update>
+      <domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0">
+        <domain:name>example.se</domain:name>
+        <domain:add>
+          <domain:ns>
+            <domain:hostObj>ns2.example.se</domain:hostObj>
+          </domain:ns>
+          <domain:contact type="tech">mak21</domain:contact>
+          <domain:status s="clientHold" lang="en">Payment overdue.</domain:status>
+        </domain:add>
+        <domain:rem>
+          <domain:status s="clientUpdateProhibited"/>
+        </domain:rem>
+        <domain:chg>
+          <domain:registrant/>
+          <domain:authInfo>
+            <domain:null/>
+          </domain:authInfo>
+        </domain:chg>
+      </domain:update>
+    </update>
+  </command>
+</epp>`))
+
+	update, err := ParseDomainUpdate(doc)
+	require.NoError(t, err)
+
+	empty := ""
+
+	assert.Equal(t, &DomainUpdate{
+		Name: "example.se",
+		Add: DomainUpdateAddRem{
+			NS:       DomainNS{HostObjs: []string{"ns2.example.se"}},
+			Contacts: []DomainContact{{Type: "tech", ID: "mak21"}},
+			Statuses: []ObjectStatus{{Status: "clientHold", Lang: "en", Reason: "Payment overdue."}},
+		},
+		Rem: DomainUpdateAddRem{
+			Statuses: []ObjectStatus{{Status: "clientUpdateProhibited"}},
+		},
+		Chg: &DomainUpdateChange{
+			Registrant: &empty,
+			AuthInfo:   &DomainAuthInfo{Null: true},
+		},
+	}, update)
+}
+
+func TestDomainInfData(t *testing.T) {
+	t.Parallel()
+
+	data := &DomainInfData{
+		Name:       "example.se",
+		ROID:       "EXAMPLE1-REP",
+		Statuses:   []ObjectStatus{{Status: "ok"}},
+		Registrant: "jd1234",
+		Contacts:   []DomainContact{{Type: "admin", ID: "sh8013"}},
+		NS:         DomainNS{HostObjs: []string{"ns1.example.se"}},
+		ClientID:   "ClientX",
+		CreateDate: time.Date(1999, 4, 3, 22, 0, 0, 0, time.UTC),
+		ExpireDate: time.Date(2005, 4, 3, 22, 0, 0, 0, time.UTC),
+		AuthInfo:   &DomainAuthInfo{Password: "2fooBAR"},
+	}
+
+	doc := NewResponseBuilder().WithResData(data.Element()).Document()
+
+	b, err := doc.WriteToBytes()
+	require.NoError(t, err)
+
+	parsed := etree.NewDocument()
+	require.NoError(t, parsed.ReadFromBytes(b))
+
+	infData := parsed.FindElement("//resData/infData")
+	require.NotNil(t, infData)
+	assert.Equal(t, NamespaceIETFDomain10.String(), infData.NamespaceURI())
+
+	var tags []string
+
+	for _, child := range infData.ChildElements() {
+		tags = append(tags, child.Tag)
+	}
+
+	assert.Equal(t, []string{"name", "roid", "status", "registrant", "contact", "ns", "clID", "crDate", "exDate", "authInfo"}, tags)
+	assert.Equal(t, "ok", infData.SelectElement("status").SelectAttrValue("s", ""))
+	assert.Equal(t, "1999-04-03T22:00:00Z", infData.SelectElement("crDate").Text())
+	assert.Equal(t, "ns1.example.se", infData.FindElement("ns/hostObj").Text())
+}
+
+func TestDomainChkData(t *testing.T) {
+	t.Parallel()
+
+	data := &DomainChkData{
+		Results: []DomainCheckResult{
+			{Name: "example.se", Available: true},
+			{Name: "example.nu", Reason: "In use", ReasonLang: "en"},
+		},
+	}
+
+	el := data.Element()
+
+	cds := el.SelectElements("cd")
+	require.Len(t, cds, 2)
+
+	assert.Equal(t, "true", cds[0].SelectElement("name").SelectAttrValue("avail", ""))
+	assert.Nil(t, cds[0].SelectElement("reason"))
+	assert.Equal(t, "false", cds[1].SelectElement("name").SelectAttrValue("avail", ""))
+	assert.Equal(t, "In use", cds[1].SelectElement("reason").Text())
+	assert.Equal(t, "en", cds[1].SelectElement("reason").SelectAttrValue("lang", ""))
+}
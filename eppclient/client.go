@@ -0,0 +1,409 @@
+// Package eppclient implements an EPP client over TLS as described in
+// https://datatracker.ietf.org/doc/html/rfc5734
+package eppclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beevik/etree"
+
+	epplib "github.com/dotse/epp-lib"
+)
+
+// ErrClosed is returned when using a closed client.
+var ErrClosed = errors.New("client is closed")
+
+// ErrUnexpectedResponse is returned when the server responds with something
+// other than an EPP response or greeting.
+var ErrUnexpectedResponse = errors.New("unexpected response")
+
+// ErrInvalidInterval is returned by KeepAlive for intervals that aren't
+// positive.
+var ErrInvalidInterval = errors.New("interval must be positive")
+
+var greetingPath = etree.MustCompilePath(epplib.NewXMLPathBuilder().
+	Add("epp", epplib.NamespaceIETFEPP10.String()).
+	Add("greeting", epplib.NamespaceIETFEPP10.String()).String())
+
+// LoginOptions are the options used to log in. Lang defaults to "en".
+type LoginOptions struct {
+	ClientID    string
+	Password    string
+	NewPassword string
+	Lang        string
+	ObjURIs     []string
+	ExtURIs     []string
+}
+
+// Client is an EPP client. It is safe for concurrent use but commands are sent
+// one at a time since EPP doesn't support pipelining. If a command fails to be
+// sent or its response fails to be read, e.g. because ctx is canceled, the
+// client is closed since a partial message may be left on the connection.
+type Client struct {
+	// MaxMessageSize if set will return an error if an incoming message is
+	// bigger than the set size in bytes. 0 indicates no limit.
+	MaxMessageSize uint32
+
+	// ClientTransactionID returns the clTRID for commands sent by the client.
+	// epplib.NewTransactionID is used if not set.
+	ClientTransactionID func() string
+
+	// Logger logs errors from the keep alive.
+	Logger *slog.Logger
+
+	conn     net.Conn
+	greeting *etree.Document
+
+	// mu serializes the commands sent on conn.
+	mu sync.Mutex
+
+	// lastActivity is the unix nano time of the last sent command.
+	lastActivity atomic.Int64
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to the EPP server at addr and reads the greeting.
+func Dial(ctx context.Context, addr string, config *tls.Config) (*Client, error) {
+	dialer := &tls.Dialer{Config: config}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewClient(ctx, conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// NewClient creates a client on an established connection and reads the
+// greeting.
+func NewClient(ctx context.Context, conn net.Conn) (*Client, error) {
+	c := &Client{
+		conn:   conn,
+		closed: make(chan struct{}),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stop := c.setDeadline(ctx)
+	defer stop()
+
+	greeting, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if greeting.FindElementPath(greetingPath) == nil {
+		return nil, fmt.Errorf("%w: expected greeting", ErrUnexpectedResponse)
+	}
+
+	c.greeting = greeting
+	c.lastActivity.Store(time.Now().UnixNano())
+
+	return c, nil
+}
+
+// Greeting returns the latest greeting from the server.
+func (c *Client) Greeting() *etree.Document {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.greeting
+}
+
+// Send sends the raw command and returns the response.
+func (c *Client) Send(ctx context.Context, command []byte) (*etree.Document, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.roundTrip(ctx, command)
+}
+
+// Command sends the command document and returns the response. If the
+// response has a result with a code of 2000 or higher it is returned as an
+// *epplib.EppError together with the response.
+func (c *Client) Command(ctx context.Context, command *etree.Document) (*etree.Document, error) {
+	b, err := command.WriteToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.Send(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, resultError(response)
+}
+
+// Hello sends a hello and returns the greeting.
+func (c *Client) Hello(ctx context.Context) (*etree.Document, error) {
+	doc, epp := newDocument()
+	epp.CreateElement("hello")
+
+	b, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	greeting, err := c.roundTrip(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if greeting.FindElementPath(greetingPath) == nil {
+		return nil, fmt.Errorf("%w: expected greeting", ErrUnexpectedResponse)
+	}
+
+	c.greeting = greeting
+
+	return greeting, nil
+}
+
+// Login logs in with the options.
+func (c *Client) Login(ctx context.Context, options LoginOptions) error {
+	doc, login := c.newCommand("login")
+
+	login.CreateElement("clID").SetText(options.ClientID)
+	login.CreateElement("pw").SetText(options.Password)
+
+	if options.NewPassword != "" {
+		login.CreateElement("newPW").SetText(options.NewPassword)
+	}
+
+	opts := login.CreateElement("options")
+	opts.CreateElement("version").SetText("1.0")
+
+	lang := options.Lang
+	if lang == "" {
+		lang = "en"
+	}
+
+	opts.CreateElement("lang").SetText(lang)
+
+	svcs := login.CreateElement("svcs")
+
+	for _, uri := range options.ObjURIs {
+		svcs.CreateElement("objURI").SetText(uri)
+	}
+
+	if len(options.ExtURIs) > 0 {
+		svcExtension := svcs.CreateElement("svcExtension")
+
+		for _, uri := range options.ExtURIs {
+			svcExtension.CreateElement("extURI").SetText(uri)
+		}
+	}
+
+	_, err := c.Command(ctx, doc)
+
+	return err
+}
+
+// Logout logs out and closes the client.
+func (c *Client) Logout(ctx context.Context) error {
+	doc, _ := c.newCommand("logout")
+
+	_, err := c.Command(ctx, doc)
+	if err != nil {
+		return err
+	}
+
+	return c.Close()
+}
+
+// KeepAlive sends a hello when no command has been sent for the interval. It
+// runs in the background until ctx is canceled or the client is closed.
+// ErrInvalidInterval is returned if the interval isn't positive.
+func (c *Client) KeepAlive(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return ErrInvalidInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.closed:
+				return
+			case <-ticker.C:
+			}
+
+			if time.Since(time.Unix(0, c.lastActivity.Load())) < interval {
+				continue
+			}
+
+			c.keepAlive(ctx, interval)
+		}
+	}()
+
+	return nil
+}
+
+// keepAlive sends a hello that has to complete within the timeout.
+func (c *Client) keepAlive(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, err := c.Hello(ctx); err != nil && !errors.Is(err, ErrClosed) {
+		c.logger().ErrorContext(ctx, "keep alive hello failed",
+			slog.Any("error", err),
+		)
+	}
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	err := ErrClosed
+
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.conn.Close()
+	})
+
+	return err
+}
+
+// roundTrip sends the command and reads the response. The client is closed if
+// the command can't be sent or the response can't be read. c.mu must be held.
+func (c *Client) roundTrip(ctx context.Context, command []byte) (*etree.Document, error) {
+	select {
+	case <-c.closed:
+		return nil, ErrClosed
+	default:
+	}
+
+	buf := epplib.MessageBuffer{}
+
+	if _, err := buf.Write(command); err != nil {
+		return nil, err
+	}
+
+	stop := c.setDeadline(ctx)
+	defer stop()
+
+	c.lastActivity.Store(time.Now().UnixNano())
+
+	if err := buf.FlushTo(c.conn); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	response, err := c.read()
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// read reads one message from the connection. c.mu must be held.
+func (c *Client) read() (*etree.Document, error) {
+	msg, err := epplib.MessageReader(c.conn, c.MaxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := etree.NewDocument()
+
+	if _, err := doc.ReadFrom(msg); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// setDeadline sets the connection deadline from ctx and interrupts any
+// blocking call if ctx is canceled. The returned function must be called when
+// done.
+func (c *Client) setDeadline(ctx context.Context) func() {
+	deadline, _ := ctx.Deadline()
+	_ = c.conn.SetDeadline(deadline)
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.conn.SetDeadline(time.Now())
+	})
+
+	return func() {
+		_ = stop()
+	}
+}
+
+// newCommand creates a command document and returns it together with the
+// element of the named command.
+func (c *Client) newCommand(name string) (*etree.Document, *etree.Element) {
+	doc, epp := newDocument()
+
+	command := epp.CreateElement("command")
+	el := command.CreateElement(name)
+	command.CreateElement("clTRID").SetText(c.clientTransactionID())
+
+	return doc, el
+}
+
+func (c *Client) clientTransactionID() string {
+	if c.ClientTransactionID != nil {
+		return c.ClientTransactionID()
+	}
+
+	return epplib.NewTransactionID()
+}
+
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+
+	return slog.Default()
+}
+
+// resultError returns the first result with a code of 2000 or higher as an
+// *epplib.EppError.
+func resultError(response *etree.Document) error {
+	results := epplib.ParseResults(response)
+	if len(results) == 0 {
+		return fmt.Errorf("%w: expected result", ErrUnexpectedResponse)
+	}
+
+	for _, result := range results {
+		if result.Code >= epplib.StatusUnknownCommand {
+			return result
+		}
+	}
+
+	return nil
+}
+
+func newDocument() (*etree.Document, *etree.Element) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8" standalone="no"`)
+
+	epp := doc.CreateElement("epp")
+	epp.CreateAttr("xmlns", epplib.NamespaceIETFEPP10.String())
+
+	return doc, epp
+}
@@ -0,0 +1,285 @@
+package eppclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	epplib "github.com/dotse/epp-lib"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	var hellos atomic.Int32
+
+	addr, serverErrs := startServer(t, &hellos)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := Dial(ctx, addr, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+
+	defer c.Close()
+
+	assert.Equal(t, "epp.example.test", c.Greeting().FindElement("/epp/greeting/svID").Text())
+
+	// Commands before login are rejected by the server.
+	_, err = c.Command(ctx, domainInfo("example.se"))
+
+	var eppErr *epplib.EppError
+
+	require.ErrorAs(t, err, &eppErr)
+	assert.Equal(t, epplib.StatusCommandUseError, eppErr.Code)
+
+	err = c.Login(ctx, LoginOptions{
+		ClientID: "ClientX",
+		Password: "wrong",
+		ObjURIs:  []string{epplib.NamespaceIETFDomain10.String()},
+	})
+	require.ErrorAs(t, err, &eppErr)
+	assert.Equal(t, epplib.StatusAuthenticationError, eppErr.Code)
+
+	err = c.Login(ctx, LoginOptions{
+		ClientID: "ClientX",
+		Password: "foo-BAR2",
+		ObjURIs:  []string{epplib.NamespaceIETFDomain10.String()},
+	})
+	require.NoError(t, err)
+
+	response, err := c.Command(ctx, domainInfo("example.se"))
+	require.NoError(t, err)
+	assert.Equal(t, "1000", response.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+
+	_, err = c.Command(ctx, domainInfo("missing.se"))
+	require.ErrorAs(t, err, &eppErr)
+	assert.Equal(t, epplib.StatusObjectDoesNotExist, eppErr.Code)
+	assert.Equal(t, []epplib.Value{{
+		Element:   "name",
+		Value:     "missing.se",
+		Namespace: epplib.NamespaceIETFDomain10.String(),
+	}}, eppErr.Values)
+
+	greeting, err := c.Hello(ctx)
+	require.NoError(t, err)
+	assert.Same(t, greeting, c.Greeting())
+	assert.Equal(t, int32(1), hellos.Load())
+
+	require.ErrorIs(t, c.KeepAlive(ctx, 0), ErrInvalidInterval)
+	require.NoError(t, c.KeepAlive(ctx, 50*time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return hellos.Load() > 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, c.Logout(ctx))
+
+	_, err = c.Hello(ctx)
+	require.ErrorIs(t, err, ErrClosed)
+
+	requireNoServerErrors(t, serverErrs)
+}
+
+func TestClient_Interrupted(t *testing.T) {
+	t.Parallel()
+
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	go func() {
+		buf := epplib.MessageBuffer{}
+		_, _ = (&epplib.GreetingBuilder{ServerID: "epp.example.test"}).WriteTo(&buf)
+		_ = buf.FlushTo(serverConn)
+
+		// Read the command but never respond.
+		_, _ = io.Copy(io.Discard, serverConn)
+	}()
+
+	c, err := NewClient(context.Background(), clientConn)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = c.Command(ctx, domainInfo("example.se"))
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	// The response may still arrive so the client can't be used anymore.
+	_, err = c.Command(context.Background(), domainInfo("example.se"))
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestClient_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	// The server never sends a greeting.
+	_, err := NewClient(ctx, clientConn)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+}
+
+func domainInfo(name string) *etree.Document {
+	doc := etree.NewDocument()
+	epp := doc.CreateElement("epp")
+	epp.CreateAttr("xmlns", epplib.NamespaceIETFEPP10.String())
+
+	command := epp.CreateElement("command")
+	info := command.CreateElement("info").CreateElement("domain:info")
+	info.CreateAttr("xmlns:domain", epplib.NamespaceIETFDomain10.String())
+	info.CreateElement("domain:name").SetText(name)
+	command.CreateElement("clTRID").SetText("ABC-1")
+
+	return doc
+}
+
+// requireNoServerErrors fails the test if any server handler has reported an
+// error.
+func requireNoServerErrors(t *testing.T, errs <-chan error) {
+	t.Helper()
+
+	for {
+		select {
+		case err := <-errs:
+			require.NoError(t, err)
+		default:
+			return
+		}
+	}
+}
+
+// startServer starts a server and returns its address and a channel with the
+// errors from the handlers, since require can't be used outside of the test
+// goroutine.
+func startServer(t *testing.T, hellos *atomic.Int32) (string, <-chan error) {
+	t.Helper()
+
+	errs := make(chan error, 10)
+
+	report := func(err error) {
+		if err == nil {
+			return
+		}
+
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+
+	greeting := &epplib.GreetingBuilder{ServerID: "epp.example.test"}
+
+	cm := &epplib.CommandMux{RequireLogin: true, AutoGreetingServices: true}
+	cm.BindGreetingBuilder(greeting)
+	cm.Bind(epplib.NewXMLPathBuilder().AddOrphan("//hello", epplib.NamespaceIETFEPP10.String()).String(),
+		func(_ context.Context, rw epplib.Writer, _ *etree.Document) {
+			hellos.Add(1)
+
+			_, err := greeting.WriteTo(rw)
+			report(err)
+		},
+	)
+	cm.Bind(epplib.NewXMLPathBuilder().
+		AddOrphan("//command", epplib.NamespaceIETFEPP10.String()).
+		Add("login", epplib.NamespaceIETFEPP10.String()).String(),
+		func(ctx context.Context, rw epplib.Writer, doc *etree.Document) {
+			result := epplib.NewError(epplib.StatusSuccess)
+
+			if doc.FindElement("//login/pw").Text() == "foo-BAR2" {
+				report(epplib.SessionFromContext(ctx).Login("ClientX", "en", nil, nil))
+			} else {
+				result = epplib.NewError(epplib.StatusAuthenticationError)
+			}
+
+			report(epplib.WriteErrorResponse(rw, "", "SV-1", result))
+		},
+	)
+	cm.BindCommand("info", epplib.NamespaceIETFDomain10.String(),
+		func(_ context.Context, rw epplib.Writer, doc *etree.Document) {
+			result := epplib.NewError(epplib.StatusSuccess)
+
+			if name := doc.FindElement("//info/info/name").Text(); name != "example.se" {
+				result = epplib.NewError(epplib.StatusObjectDoesNotExist).WithValues(epplib.Value{
+					Element:   "name",
+					Value:     name,
+					Namespace: epplib.NamespaceIETFDomain10.String(),
+				})
+			}
+
+			report(epplib.WriteErrorResponse(rw, "", "SV-1", result))
+		},
+	)
+
+	s := &epplib.Server{
+		HandleCommand: cm.Handle,
+		Greeting:      cm.GetGreeting,
+		TLSConfig: tls.Config{
+			Certificates: []tls.Certificate{generateCertificate(t)},
+		},
+		IdleTimeout: 10 * time.Second,
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		_ = s.Serve(listener.(*net.TCPListener))
+	}()
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return listener.Addr().String(), errs
+}
+
+func generateCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(1653),
+		Subject: pkix.Name{
+			CommonName: "epp.example.test",
+		},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(0, 0, 1),
+		IsCA:                  true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	certificate, err := x509.CreateCertificate(rand.Reader, cert, cert, key.Public(), key)
+	require.NoError(t, err)
+
+	return tls.Certificate{
+		Certificate: [][]byte{certificate},
+		PrivateKey:  key,
+	}
+}
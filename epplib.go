@@ -25,3 +25,8 @@ type KeepAliveConn interface {
 	SetKeepAlive(b bool) error
 	SetKeepAlivePeriod(d time.Duration) error
 }
+
+// KeepAliveConfigConn can set keep alive information with a KeepAliveConfig.
+type KeepAliveConfigConn interface {
+	SetKeepAliveConfig(config net.KeepAliveConfig) error
+}
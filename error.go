@@ -4,10 +4,12 @@ import (
 	"fmt"
 )
 
-// Value represent the value element in an EPP error.
+// Value represent the value element in an EPP error. Namespace is optional
+// and defaults to the EPP namespace.
 type Value struct {
-	Element string
-	Value   string
+	Element   string
+	Value     string
+	Namespace string
 }
 
 // ExtValue represent the extvalue element in an EPP error.
@@ -16,12 +18,15 @@ type ExtValue struct {
 	Value     string
 	Namespace string
 	Reason    string
+	Lang      string
 }
 
-// EppError represent the data needed for an EPP error.
+// EppError represent the data needed for an EPP error. Lang is the language
+// of Message, if empty no lang attribute is written and "en" is implied.
 type EppError struct {
 	Code      int
 	Message   string
+	Lang      string
 	Values    []Value
 	ExtValues []ExtValue
 }
@@ -0,0 +1,181 @@
+package epplib
+
+import (
+	"io"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// Data collection policy access values as described in
+// https://datatracker.ietf.org/doc/html/rfc5730#section-2.4
+const (
+	DCPAccessAll              = "all"
+	DCPAccessNone             = "none"
+	DCPAccessNull             = "null"
+	DCPAccessOther            = "other"
+	DCPAccessPersonal         = "personal"
+	DCPAccessPersonalAndOther = "personalAndOther"
+)
+
+// Data collection policy purpose, recipient and retention values as described
+// in https://datatracker.ietf.org/doc/html/rfc5730#section-2.4
+const (
+	DCPPurposeAdmin   = "admin"
+	DCPPurposeContact = "contact"
+	DCPPurposeOther   = "other"
+	DCPPurposeProv    = "prov"
+
+	DCPRecipientOther     = "other"
+	DCPRecipientOurs      = "ours"
+	DCPRecipientPublic    = "public"
+	DCPRecipientSame      = "same"
+	DCPRecipientUnrelated = "unrelated"
+
+	DCPRetentionBusiness   = "business"
+	DCPRetentionIndefinite = "indefinite"
+	DCPRetentionLegal      = "legal"
+	DCPRetentionNone       = "none"
+	DCPRetentionStated     = "stated"
+)
+
+// DCP represent the data collection policy of a greeting. If Access is empty
+// DCPAccessAll is used and if there are no statements a single statement with
+// the admin and prov purposes, ours recipient and stated retention is used.
+type DCP struct {
+	Access     string
+	Statements []DCPStatement
+	Expiry     *DCPExpiry
+}
+
+// DCPStatement represent a statement element in the data collection policy.
+type DCPStatement struct {
+	Purposes   []string
+	Recipients []string
+	Retention  string
+}
+
+// DCPExpiry represent the expiry element in the data collection policy. Either
+// Absolute or Relative (an XML Schema duration, e.g. "P1Y") should be set.
+type DCPExpiry struct {
+	Absolute time.Time
+	Relative string
+}
+
+// GreetingBuilder builds an EPP greeting. If ServerDate is zero the current
+// time is used. Versions and Langs defaults to "1.0" and "en".
+type GreetingBuilder struct {
+	ServerID   string
+	ServerDate time.Time
+	Versions   []string
+	Langs      []string
+	ObjURIs    []string
+	ExtURIs    []string
+	DCP        DCP
+}
+
+// Document builds the greeting document.
+func (g *GreetingBuilder) Document() *etree.Document {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8" standalone="no"`)
+
+	epp := doc.CreateElement("epp")
+	epp.CreateAttr("xmlns", NamespaceIETFEPP10.String())
+
+	greeting := epp.CreateElement("greeting")
+	greeting.CreateElement("svID").SetText(g.ServerID)
+
+	svDate := g.ServerDate
+	if svDate.IsZero() {
+		svDate = time.Now()
+	}
+
+	greeting.CreateElement("svDate").SetText(formatDateTime(svDate))
+
+	svcMenu := greeting.CreateElement("svcMenu")
+
+	for _, version := range defaultStrings(g.Versions, "1.0") {
+		svcMenu.CreateElement("version").SetText(version)
+	}
+
+	for _, lang := range defaultStrings(g.Langs, "en") {
+		svcMenu.CreateElement("lang").SetText(lang)
+	}
+
+	for _, uri := range g.ObjURIs {
+		svcMenu.CreateElement("objURI").SetText(uri)
+	}
+
+	if len(g.ExtURIs) > 0 {
+		svcExtension := svcMenu.CreateElement("svcExtension")
+
+		for _, uri := range g.ExtURIs {
+			svcExtension.CreateElement("extURI").SetText(uri)
+		}
+	}
+
+	createDCPElement(greeting, &g.DCP)
+
+	return doc
+}
+
+// WriteTo writes the greeting document on w.
+func (g *GreetingBuilder) WriteTo(w io.Writer) (int64, error) {
+	return g.Document().WriteTo(w)
+}
+
+func createDCPElement(parent *etree.Element, dcp *DCP) {
+	el := parent.CreateElement("dcp")
+
+	access := dcp.Access
+	if access == "" {
+		access = DCPAccessAll
+	}
+
+	el.CreateElement("access").CreateElement(access)
+
+	statements := dcp.Statements
+	if len(statements) == 0 {
+		statements = []DCPStatement{{
+			Purposes:   []string{DCPPurposeAdmin, DCPPurposeProv},
+			Recipients: []string{DCPRecipientOurs},
+			Retention:  DCPRetentionStated,
+		}}
+	}
+
+	for _, s := range statements {
+		statement := el.CreateElement("statement")
+
+		purpose := statement.CreateElement("purpose")
+
+		for _, p := range s.Purposes {
+			purpose.CreateElement(p)
+		}
+
+		recipient := statement.CreateElement("recipient")
+
+		for _, r := range s.Recipients {
+			recipient.CreateElement(r)
+		}
+
+		statement.CreateElement("retention").CreateElement(s.Retention)
+	}
+
+	if dcp.Expiry != nil {
+		expiry := el.CreateElement("expiry")
+
+		if dcp.Expiry.Relative != "" {
+			expiry.CreateElement("relative").SetText(dcp.Expiry.Relative)
+		} else {
+			expiry.CreateElement("absolute").SetText(formatDateTime(dcp.Expiry.Absolute))
+		}
+	}
+}
+
+func defaultStrings(values []string, def string) []string {
+	if len(values) == 0 {
+		return []string{def}
+	}
+
+	return values
+}
@@ -0,0 +1,88 @@
+package epplib
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGreetingBuilder(t *testing.T) {
+	t.Parallel()
+
+	g := &GreetingBuilder{
+		ServerID:   "Example EPP server epp.example.se",
+		ServerDate: time.Date(2000, 6, 8, 22, 0, 0, 0, time.UTC),
+		Langs:      []string{"en", "sv"},
+		ObjURIs:    []string{NamespaceIETFDomain10.String(), NamespaceIETFContact10.String()},
+		ExtURIs:    []string{NamespaceIETFSecDNS11.String()},
+		DCP: DCP{
+			Access: DCPAccessPersonalAndOther,
+			Statements: []DCPStatement{
+				{
+					Purposes:   []string{DCPPurposeAdmin, DCPPurposeContact},
+					Recipients: []string{DCPRecipientOurs, DCPRecipientPublic},
+					Retention:  DCPRetentionStated,
+				},
+			},
+			Expiry: &DCPExpiry{Relative: "P1Y"},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	_, err := g.WriteTo(&buf)
+	require.NoError(t, err)
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromBytes(buf.Bytes()))
+
+	greeting := doc.FindElement("/epp/greeting")
+	require.NotNil(t, greeting)
+	assert.Equal(t, NamespaceIETFEPP10.String(), greeting.NamespaceURI())
+
+	assert.Equal(t, "Example EPP server epp.example.se", greeting.SelectElement("svID").Text())
+	assert.Equal(t, "2000-06-08T22:00:00Z", greeting.SelectElement("svDate").Text())
+	assert.Equal(t, []string{"1.0"}, texts(greeting.FindElements("svcMenu/version")))
+	assert.Equal(t, []string{"en", "sv"}, texts(greeting.FindElements("svcMenu/lang")))
+	assert.Equal(t, g.ObjURIs, texts(greeting.FindElements("svcMenu/objURI")))
+	assert.Equal(t, g.ExtURIs, texts(greeting.FindElements("svcMenu/svcExtension/extURI")))
+
+	assert.NotNil(t, greeting.FindElement("dcp/access/personalAndOther"))
+	assert.NotNil(t, greeting.FindElement("dcp/statement/purpose/admin"))
+	assert.NotNil(t, greeting.FindElement("dcp/statement/purpose/contact"))
+	assert.NotNil(t, greeting.FindElement("dcp/statement/recipient/ours"))
+	assert.NotNil(t, greeting.FindElement("dcp/statement/recipient/public"))
+	assert.NotNil(t, greeting.FindElement("dcp/statement/retention/stated"))
+	assert.Equal(t, "P1Y", greeting.FindElement("dcp/expiry/relative").Text())
+}
+
+func TestGreetingBuilder_Defaults(t *testing.T) {
+	t.Parallel()
+
+	doc := (&GreetingBuilder{ServerID: "epp"}).Document()
+
+	greeting := doc.FindElement("/epp/greeting")
+	require.NotNil(t, greeting)
+
+	assert.NotEmpty(t, greeting.SelectElement("svDate").Text())
+	assert.Equal(t, []string{"1.0"}, texts(greeting.FindElements("svcMenu/version")))
+	assert.Equal(t, []string{"en"}, texts(greeting.FindElements("svcMenu/lang")))
+	assert.Nil(t, greeting.FindElement("svcMenu/svcExtension"))
+	assert.NotNil(t, greeting.FindElement("dcp/access/all"))
+	assert.NotNil(t, greeting.FindElement("dcp/statement/purpose/prov"))
+	assert.Nil(t, greeting.FindElement("dcp/expiry"))
+}
+
+func texts(elements []*etree.Element) []string {
+	s := make([]string, 0, len(elements))
+
+	for _, el := range elements {
+		s = append(s, el.Text())
+	}
+
+	return s
+}
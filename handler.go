@@ -12,7 +12,31 @@ import (
 // type HandlerFunc func(context.Context, *ResponseWriter, *etree.Document).
 type CommandFunc func(context.Context, Writer, *etree.Document)
 
+// Middleware wraps a CommandFunc to add behavior before or after it, e.g.
+// logging, metrics or authorization checks.
+type Middleware func(CommandFunc) CommandFunc
+
 type handler struct {
-	fn   CommandFunc
-	path etree.Path
+	fn         CommandFunc
+	path       etree.Path
+	middleware []Middleware
+
+	// namespaces are the namespace uris referenced by the path.
+	namespaces []string
+}
+
+// commandFunc returns the handler function wrapped by its middleware and the
+// given outer middleware. The first middleware is the outermost.
+func (h handler) commandFunc(outer []Middleware) CommandFunc {
+	fn := h.fn
+
+	for i := len(h.middleware) - 1; i >= 0; i-- {
+		fn = h.middleware[i](fn)
+	}
+
+	for i := len(outer) - 1; i >= 0; i-- {
+		fn = outer[i](fn)
+	}
+
+	return fn
 }
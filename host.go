@@ -0,0 +1,271 @@
+package epplib
+
+import (
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// Host statuses as described in https://datatracker.ietf.org/doc/html/rfc5732#section-2.3
+var hostStatuses = []string{
+	"clientDeleteProhibited",
+	"clientUpdateProhibited",
+	"linked",
+	"ok",
+	"pendingCreate",
+	"pendingDelete",
+	"pendingTransfer",
+	"pendingUpdate",
+	"serverDeleteProhibited",
+	"serverUpdateProhibited",
+}
+
+// HostCheck represent a host check command.
+type HostCheck struct {
+	Names []string
+}
+
+// HostInfo represent a host info command.
+type HostInfo struct {
+	Name string
+}
+
+// HostCreate represent a host create command.
+type HostCreate struct {
+	Name  string
+	Addrs []netip.Addr
+}
+
+// HostDelete represent a host delete command.
+type HostDelete struct {
+	Name string
+}
+
+// HostUpdate represent a host update command. An empty NewName leaves the
+// name unchanged.
+type HostUpdate struct {
+	Name    string
+	Add     HostUpdateAddRem
+	Rem     HostUpdateAddRem
+	NewName string
+}
+
+// HostUpdateAddRem represent the add and rem elements of a host update.
+type HostUpdateAddRem struct {
+	Addrs    []netip.Addr
+	Statuses []ObjectStatus
+}
+
+// ParseHostCheck parses a host check command.
+func ParseHostCheck(doc *etree.Document) (*HostCheck, error) {
+	el, err := hostCommandElement(doc, "check")
+	if err != nil {
+		return nil, err
+	}
+
+	names := childTexts(el, "name", NamespaceIETFHost10.String())
+	if len(names) == 0 || slices.Contains(names, "") {
+		return nil, missingParameterError("name", NamespaceIETFHost10.String())
+	}
+
+	return &HostCheck{Names: names}, nil
+}
+
+// ParseHostInfo parses a host info command.
+func ParseHostInfo(doc *etree.Document) (*HostInfo, error) {
+	el, err := hostCommandElement(doc, "info")
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := requiredChildText(el, "name", NamespaceIETFHost10.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &HostInfo{Name: name}, nil
+}
+
+// ParseHostCreate parses a host create command.
+func ParseHostCreate(doc *etree.Document) (*HostCreate, error) {
+	el, err := hostCommandElement(doc, "create")
+	if err != nil {
+		return nil, err
+	}
+
+	create := &HostCreate{}
+
+	if create.Name, err = requiredChildText(el, "name", NamespaceIETFHost10.String()); err != nil {
+		return nil, err
+	}
+
+	if create.Addrs, err = parseHostAddrs(el); err != nil {
+		return nil, err
+	}
+
+	return create, nil
+}
+
+// ParseHostDelete parses a host delete command.
+func ParseHostDelete(doc *etree.Document) (*HostDelete, error) {
+	el, err := hostCommandElement(doc, "delete")
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := requiredChildText(el, "name", NamespaceIETFHost10.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &HostDelete{Name: name}, nil
+}
+
+// ParseHostUpdate parses a host update command.
+func ParseHostUpdate(doc *etree.Document) (*HostUpdate, error) {
+	el, err := hostCommandElement(doc, "update")
+	if err != nil {
+		return nil, err
+	}
+
+	update := &HostUpdate{}
+
+	if update.Name, err = requiredChildText(el, "name", NamespaceIETFHost10.String()); err != nil {
+		return nil, err
+	}
+
+	if add := childElement(el, "add", NamespaceIETFHost10.String()); add != nil {
+		if update.Add, err = parseHostUpdateAddRem(add); err != nil {
+			return nil, err
+		}
+	}
+
+	if rem := childElement(el, "rem", NamespaceIETFHost10.String()); rem != nil {
+		if update.Rem, err = parseHostUpdateAddRem(rem); err != nil {
+			return nil, err
+		}
+	}
+
+	if chg := childElement(el, "chg", NamespaceIETFHost10.String()); chg != nil {
+		if update.NewName, err = requiredChildText(chg, "name", NamespaceIETFHost10.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	return update, nil
+}
+
+func hostCommandElement(doc *etree.Document, command string) (*etree.Element, error) {
+	return commandObjectElement(doc, command, NamespaceIETFHost10.String())
+}
+
+func parseHostAddrs(el *etree.Element) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+
+	for _, addrEl := range childElements(el, "addr", NamespaceIETFHost10.String()) {
+		addr, err := parseIPAddress(addrEl, NamespaceIETFHost10.String())
+		if err != nil {
+			return nil, err
+		}
+
+		addrs = append(addrs, addr)
+	}
+
+	return addrs, nil
+}
+
+func parseHostUpdateAddRem(el *etree.Element) (HostUpdateAddRem, error) {
+	var (
+		result HostUpdateAddRem
+		err    error
+	)
+
+	if result.Addrs, err = parseHostAddrs(el); err != nil {
+		return result, err
+	}
+
+	if result.Statuses, err = parseObjectStatuses(el, NamespaceIETFHost10.String(), hostStatuses); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// HostCheckResult represent the cd element of a host check response.
+type HostCheckResult struct {
+	Name       string
+	Available  bool
+	Reason     string
+	ReasonLang string
+}
+
+// HostChkData represent the chkData element of a host check response.
+type HostChkData struct {
+	Results []HostCheckResult
+}
+
+// Element returns the chkData element.
+func (h *HostChkData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFHost10.String(), "chkData")
+
+	for _, result := range h.Results {
+		createCheckDataChild(el, "name", result.Name, result.Available, result.Reason, result.ReasonLang)
+	}
+
+	return el
+}
+
+// HostInfData represent the infData element of a host info response.
+type HostInfData struct {
+	Name           string
+	ROID           string
+	Statuses       []ObjectStatus
+	Addrs          []netip.Addr
+	ClientID       string
+	CreateClientID string
+	CreateDate     time.Time
+	UpdateClientID string
+	UpdateDate     time.Time
+	TransferDate   time.Time
+}
+
+// Element returns the infData element.
+func (h *HostInfData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFHost10.String(), "infData")
+
+	createTextChild(el, "name", h.Name)
+	createTextChild(el, "roid", h.ROID)
+
+	createStatusChildren(el, h.Statuses)
+
+	for _, addr := range h.Addrs {
+		createIPAddressChild(el, "addr", addr)
+	}
+
+	createTextChild(el, "clID", h.ClientID)
+	createTextChild(el, "crID", h.CreateClientID)
+	createTextChild(el, "crDate", formatDateTime(h.CreateDate))
+	createOptionalTextChild(el, "upID", h.UpdateClientID)
+	createOptionalDateTimeChild(el, "upDate", h.UpdateDate)
+	createOptionalDateTimeChild(el, "trDate", h.TransferDate)
+
+	return el
+}
+
+// HostCreData represent the creData element of a host create response.
+type HostCreData struct {
+	Name       string
+	CreateDate time.Time
+}
+
+// Element returns the creData element.
+func (h *HostCreData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFHost10.String(), "creData")
+
+	createTextChild(el, "name", h.Name)
+	createTextChild(el, "crDate", formatDateTime(h.CreateDate))
+
+	return el
+}
@@ -0,0 +1,170 @@
+package epplib
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHostCreate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name          string
+		addrs         string
+		expectedAddrs []netip.Addr
+		expectedValue string
+	}{
+		{
+			name:          "no addresses",
+			expectedAddrs: nil,
+		},
+		{
+			name:  "v4 and v6",
+			addrs: `<host:addr ip="v4">192.0.2.2</host:addr><host:addr ip="v6">1080:0:0:0:8:800:200C:417A</host:addr>`,
+			expectedAddrs: []netip.Addr{
+				netip.MustParseAddr("192.0.2.2"),
+				netip.MustParseAddr("1080::8:800:200c:417a"),
+			},
+		},
+		{
+			name:          "ip defaults to v4",
+			addrs:         `<host:addr>192.0.2.29</host:addr>`,
+			expectedAddrs: []netip.Addr{netip.MustParseAddr("192.0.2.29")},
+		},
+		{
+			name:          "v6 literal marked as v4",
+			addrs:         `<host:addr>2001:db8::1</host:addr>`,
+			expectedValue: "2001:db8::1",
+		},
+		{
+			name:          "v4 literal marked as v6",
+			addrs:         `<host:addr ip="v6">192.0.2.2</host:addr>`,
+			expectedValue: "192.0.2.2",
+		},
+		{
+			name:          "v4 mapped v6 literal",
+			addrs:         `<host:addr ip="v6">::ffff:192.0.2.2</host:addr>`,
+			expectedValue: "::ffff:192.0.2.2",
+		},
+		{
+			name:          "zone",
+			addrs:         `<host:addr ip="v6">fe80::1%eth0</host:addr>`,
+			expectedValue: "fe80::1%eth0",
+		},
+		{
+			name:          "invalid ip attribute",
+			addrs:         `<host:addr ip="v5">192.0.2.2</host:addr>`,
+			expectedValue: "192.0.2.2",
+		},
+		{
+			name:          "not an address",
+			addrs:         `<host:addr>ns1.example.se</host:addr>`,
+			expectedValue: "ns1.example.se",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromString(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><create><host:create xmlns:host="urn:ietf:params:xml:ns:host-1.0"><host:name>ns1.example.se</host:name>`+tc.addrs+`</host:create></create></command></epp>`))
+
+			create, err := ParseHostCreate(doc)
+
+			if tc.expectedValue != "" {
+				var eppErr *EppError
+
+				require.True(t, errors.As(err, &eppErr))
+				assert.Equal(t, StatusValueSyntaxError, eppErr.Code)
+				assert.Equal(t, []Value{{
+					Element:   "addr",
+					Value:     tc.expectedValue,
+					Namespace: NamespaceIETFHost10.String(),
+				}}, eppErr.Values)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, &HostCreate{Name: "ns1.example.se", Addrs: tc.expectedAddrs}, create)
+		})
+	}
+}
+
+func TestParseHostUpdate(t *testing.T) {
+	t.Parallel()
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromString(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <update>
+      <host:update xmlns:host="urn:ietf:params:xml:ns:host-1.0">
+        <host:name>ns1.example.se</host:name>
+        <host:add>
+          <host:addr ip="v4">192.0.2.22</host:addr>
+          <host:status s="clientUpdateProhibited"/>
+        </host:add>
+        <host:rem>
+          <host:addr ip="v6">1080:0:0:0:8:800:200C:417A</host:addr>
+        </host:rem>
+        <host:chg>
+          <host:name>ns2.example.se</host:name>
+        </host:chg>
+      </host:update>
+    </update>
+  </command>
+</epp>`))
+
+	update, err := ParseHostUpdate(doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, &HostUpdate{
+		Name: "ns1.example.se",
+		Add: HostUpdateAddRem{
+			Addrs:    []netip.Addr{netip.MustParseAddr("192.0.2.22")},
+			Statuses: []ObjectStatus{{Status: "clientUpdateProhibited"}},
+		},
+		Rem: HostUpdateAddRem{
+			Addrs: []netip.Addr{netip.MustParseAddr("1080::8:800:200c:417a")},
+		},
+		NewName: "ns2.example.se",
+	}, update)
+}
+
+func TestHostInfData(t *testing.T) {
+	t.Parallel()
+
+	data := &HostInfData{
+		Name:     "ns1.example.se",
+		ROID:     "NS1_EXAMPLE1-REP",
+		Statuses: []ObjectStatus{{Status: "linked"}},
+		Addrs: []netip.Addr{
+			netip.MustParseAddr("192.0.2.2"),
+			netip.MustParseAddr("2001:db8::1"),
+		},
+		ClientID:       "ClientY",
+		CreateClientID: "ClientX",
+		CreateDate:     time.Date(1999, 4, 3, 22, 0, 0, 0, time.UTC),
+	}
+
+	el := data.Element()
+
+	var tags []string
+
+	for _, child := range el.ChildElements() {
+		tags = append(tags, child.Tag)
+	}
+
+	assert.Equal(t, []string{"name", "roid", "status", "addr", "addr", "clID", "crID", "crDate"}, tags)
+
+	addrs := el.SelectElements("addr")
+	assert.Equal(t, "v4", addrs[0].SelectAttrValue("ip", ""))
+	assert.Equal(t, "192.0.2.2", addrs[0].Text())
+	assert.Equal(t, "v6", addrs[1].SelectAttrValue("ip", ""))
+	assert.Equal(t, "2001:db8::1", addrs[1].Text())
+}
@@ -0,0 +1,168 @@
+package epplib
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// orgNoRegexp matches an organisation number prefixed with the country code
+// in brackets, e.g. [SE]802405-0190.
+var orgNoRegexp = regexp.MustCompile(`^\[[A-Z]{2}\].+$`)
+
+// IISContactCreate represent the iis create extension of a contact create
+// command.
+type IISContactCreate struct {
+	OrgNo string
+	VatNo string
+}
+
+// IISContactUpdate represent the iis update extension of a contact update
+// command. Only the VAT number can be changed.
+type IISContactUpdate struct {
+	VatNo string
+}
+
+// IISDomainUpdate represent the iis update extension of a domain update
+// command. A nil ClientDelete means that it isn't changed.
+type IISDomainUpdate struct {
+	ClientDelete *bool
+}
+
+// ParseIISContactCreate parses the iis extension of a contact create command.
+// If the command has no iis extension nil is returned.
+func ParseIISContactCreate(doc *etree.Document) (*IISContactCreate, error) {
+	ns := NamespaceIISEpp12.String()
+
+	el, err := iisCommandExtension(doc, "create", NamespaceIETFContact10)
+	if el == nil {
+		return nil, err
+	}
+
+	orgNo, err := requiredChildText(el, "orgno", ns)
+	if err != nil {
+		return nil, err
+	}
+
+	if !orgNoRegexp.MatchString(orgNo) {
+		return nil, syntaxError("orgno", ns, orgNo)
+	}
+
+	return &IISContactCreate{
+		OrgNo: orgNo,
+		VatNo: childText(el, "vatno", ns),
+	}, nil
+}
+
+// ParseIISContactUpdate parses the iis extension of a contact update command.
+// If the command has no iis extension nil is returned.
+func ParseIISContactUpdate(doc *etree.Document) (*IISContactUpdate, error) {
+	ns := NamespaceIISEpp12.String()
+
+	el, err := iisCommandExtension(doc, "update", NamespaceIETFContact10)
+	if el == nil {
+		return nil, err
+	}
+
+	if childElement(el, "vatno", ns) == nil {
+		return nil, missingParameterError("vatno", ns)
+	}
+
+	return &IISContactUpdate{
+		VatNo: childText(el, "vatno", ns),
+	}, nil
+}
+
+// ParseIISDomainUpdate parses the iis extension of a domain update command.
+// If the command has no iis extension nil is returned.
+func ParseIISDomainUpdate(doc *etree.Document) (*IISDomainUpdate, error) {
+	ns := NamespaceIISEpp12.String()
+
+	el, err := iisCommandExtension(doc, "update", NamespaceIETFDomain10)
+	if el == nil {
+		return nil, err
+	}
+
+	update := &IISDomainUpdate{}
+
+	if clientDelete := childElement(el, "clientDelete", ns); clientDelete != nil {
+		value, err := ParseXMLBool(clientDelete.Text())
+		if err != nil {
+			return nil, syntaxError("clientDelete", ns, strings.TrimSpace(clientDelete.Text()))
+		}
+
+		update.ClientDelete = &value
+	}
+
+	return update, nil
+}
+
+// iisCommandExtension returns the iis extension element with the tag of a
+// command for the object. If the command is for another object a 2306 error
+// is returned.
+func iisCommandExtension(doc *etree.Document, tag string, object Namespace) (*etree.Element, error) {
+	ns := NamespaceIISEpp12.String()
+
+	el := commandExtensionElement(doc, tag, ns)
+	if el == nil {
+		return nil, nil
+	}
+
+	if ParseCommandInfo(doc).Namespace != object.String() {
+		return nil, NewError(StatusParameterPolicyError).WithValues(Value{
+			Element:   tag,
+			Namespace: ns,
+		})
+	}
+
+	return el, nil
+}
+
+// IISContactInfData represent the infData element of a contact info response
+// extension.
+type IISContactInfData struct {
+	OrgNo string
+	VatNo string
+}
+
+// Element returns the infData element.
+func (d *IISContactInfData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIISEpp12.String(), "infData")
+
+	createOptionalTextChild(el, "orgno", d.OrgNo)
+	createOptionalTextChild(el, "vatno", d.VatNo)
+
+	return el
+}
+
+// IISDomainInfData represent the infData element of a domain info response
+// extension. The dates are encoded as XML Schema dates and are omitted when
+// zero.
+type IISDomainInfData struct {
+	State        string
+	DelDate      time.Time
+	DeactDate    time.Time
+	RelDate      time.Time
+	ClientDelete bool
+}
+
+// Element returns the infData element.
+func (d *IISDomainInfData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIISEpp12.String(), "infData")
+
+	createOptionalTextChild(el, "state", d.State)
+	createOptionalIISDateChild(el, "delDate", d.DelDate)
+	createOptionalIISDateChild(el, "deactDate", d.DeactDate)
+	createOptionalIISDateChild(el, "relDate", d.RelDate)
+	createTextChild(el, "clientDelete", FormatXMLBool(d.ClientDelete))
+
+	return el
+}
+
+func createOptionalIISDateChild(el *etree.Element, tag string, t time.Time) {
+	if !t.IsZero() {
+		createTextChild(el, tag, t.Format(time.DateOnly))
+	}
+}
@@ -0,0 +1,206 @@
+package epplib
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIISContactCreate(t *testing.T) {
+	t.Parallel()
+
+	const contact = `<contact:create xmlns:contact="urn:ietf:params:xml:ns:contact-1.0"><contact:id>sh8013</contact:id></contact:create>`
+
+	for _, tc := range []struct {
+		name          string
+		extension     string
+		expected      *IISContactCreate
+		expectedCode  int
+		expectedValue Value
+	}{
+		{
+			name: "no extension",
+		},
+		{
+			name:      "orgno and vatno",
+			extension: `<iis:create xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:orgno>[SE]802405-0190</iis:orgno><iis:vatno>SE802405019001</iis:vatno></iis:create>`,
+			expected:  &IISContactCreate{OrgNo: "[SE]802405-0190", VatNo: "SE802405019001"},
+		},
+		{
+			name:         "missing orgno",
+			extension:    `<iis:create xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:vatno>SE802405019001</iis:vatno></iis:create>`,
+			expectedCode: StatusMissingParameter,
+			expectedValue: Value{
+				Element:   "orgno",
+				Namespace: NamespaceIISEpp12.String(),
+			},
+		},
+		{
+			name:         "orgno without country code",
+			extension:    `<iis:create xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:orgno>802405-0190</iis:orgno></iis:create>`,
+			expectedCode: StatusValueSyntaxError,
+			expectedValue: Value{
+				Element:   "orgno",
+				Value:     "802405-0190",
+				Namespace: NamespaceIISEpp12.String(),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			create, err := ParseIISContactCreate(commandDocument(t, "create", contact, tc.extension))
+
+			if tc.expectedCode != 0 {
+				var eppErr *EppError
+
+				require.True(t, errors.As(err, &eppErr))
+				assert.Equal(t, tc.expectedCode, eppErr.Code)
+				assert.Equal(t, []Value{tc.expectedValue}, eppErr.Values)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, create)
+		})
+	}
+}
+
+func TestParseIISContactUpdate(t *testing.T) {
+	t.Parallel()
+
+	update, err := ParseIISContactUpdate(commandDocument(t, "update",
+		`<contact:update xmlns:contact="urn:ietf:params:xml:ns:contact-1.0"><contact:id>sh8013</contact:id></contact:update>`,
+		`<iis:update xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:vatno>SE802405019001</iis:vatno></iis:update>`,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, &IISContactUpdate{VatNo: "SE802405019001"}, update)
+}
+
+func TestParseIISUpdate_WrongObject(t *testing.T) {
+	t.Parallel()
+
+	const (
+		contact = `<contact:update xmlns:contact="urn:ietf:params:xml:ns:contact-1.0"><contact:id>sh8013</contact:id></contact:update>`
+		domain  = `<domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:update>`
+	)
+
+	expected := []Value{{Element: "update", Namespace: NamespaceIISEpp12.String()}}
+
+	var eppErr *EppError
+
+	_, err := ParseIISContactUpdate(commandDocument(t, "update", domain,
+		`<iis:update xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:clientDelete>1</iis:clientDelete></iis:update>`,
+	))
+	require.ErrorAs(t, err, &eppErr)
+	assert.Equal(t, StatusParameterPolicyError, eppErr.Code)
+	assert.Equal(t, expected, eppErr.Values)
+
+	_, err = ParseIISDomainUpdate(commandDocument(t, "update", contact,
+		`<iis:update xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:vatno>SE802405019001</iis:vatno></iis:update>`,
+	))
+	require.ErrorAs(t, err, &eppErr)
+	assert.Equal(t, StatusParameterPolicyError, eppErr.Code)
+	assert.Equal(t, expected, eppErr.Values)
+}
+
+func TestParseIISDomainUpdate(t *testing.T) {
+	t.Parallel()
+
+	const domain = `<domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:update>`
+
+	yes := true
+	no := false
+
+	for _, tc := range []struct {
+		name          string
+		extension     string
+		expected      *IISDomainUpdate
+		expectedValue string
+	}{
+		{
+			name: "no extension",
+		},
+		{
+			name:      "clientDelete",
+			extension: `<iis:update xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:clientDelete>1</iis:clientDelete></iis:update>`,
+			expected:  &IISDomainUpdate{ClientDelete: &yes},
+		},
+		{
+			name:      "clientDelete false",
+			extension: `<iis:update xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:clientDelete>false</iis:clientDelete></iis:update>`,
+			expected:  &IISDomainUpdate{ClientDelete: &no},
+		},
+		{
+			name:      "no change",
+			extension: `<iis:update xmlns:iis="urn:se:iis:xml:epp:iis-1.2"/>`,
+			expected:  &IISDomainUpdate{},
+		},
+		{
+			name:          "invalid clientDelete",
+			extension:     `<iis:update xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:clientDelete>yes</iis:clientDelete></iis:update>`,
+			expectedValue: "yes",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			update, err := ParseIISDomainUpdate(commandDocument(t, "update", domain, tc.extension))
+
+			if tc.expectedValue != "" {
+				var eppErr *EppError
+
+				require.True(t, errors.As(err, &eppErr))
+				assert.Equal(t, StatusValueSyntaxError, eppErr.Code)
+				assert.Equal(t, []Value{{
+					Element:   "clientDelete",
+					Value:     tc.expectedValue,
+					Namespace: NamespaceIISEpp12.String(),
+				}}, eppErr.Values)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, update)
+		})
+	}
+}
+
+func TestIISDomainInfData(t *testing.T) {
+	t.Parallel()
+
+	el := (&IISDomainInfData{
+		State:        "serverHold",
+		DelDate:      time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+		DeactDate:    time.Date(2025, 12, 16, 0, 0, 0, 0, time.UTC),
+		ClientDelete: true,
+	}).Element()
+
+	assert.Equal(t, "iis:infData", el.FullTag())
+	assert.Equal(t, NamespaceIISEpp12.String(), el.SelectAttrValue("xmlns:iis", ""))
+
+	var tags []string
+
+	for _, child := range el.ChildElements() {
+		tags = append(tags, child.Tag)
+	}
+
+	assert.Equal(t, []string{"state", "delDate", "deactDate", "clientDelete"}, tags)
+	assert.Equal(t, "2026-01-15", el.SelectElement("delDate").Text())
+	assert.Equal(t, "2025-12-16", el.SelectElement("deactDate").Text())
+	assert.Equal(t, "true", el.SelectElement("clientDelete").Text())
+}
+
+func TestIISContactInfData(t *testing.T) {
+	t.Parallel()
+
+	el := (&IISContactInfData{OrgNo: "[SE]802405-0190"}).Element()
+
+	require.Len(t, el.ChildElements(), 1)
+	assert.Equal(t, "[SE]802405-0190", el.SelectElement("orgno").Text())
+}
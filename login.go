@@ -0,0 +1,142 @@
+package epplib
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/beevik/etree"
+)
+
+// ErrAuthenticationFailed is returned by an Authenticator when the password
+// isn't valid for the client.
+var ErrAuthenticationFailed = errors.New("authentication failed")
+
+// Login represent the login command.
+type Login struct {
+	ClientID    string
+	Password    string
+	NewPassword string
+	Version     string
+	Lang        string
+	ObjURIs     []string
+	ExtURIs     []string
+}
+
+// Authenticator authenticates the client of a login command. The certificate
+// is the client certificate of the connection or nil if there is none.
+// ErrAuthenticationFailed, possibly wrapped, results in 2200 and a returned
+// *EppError is used as the result of the login, both count as failed logins.
+// Any other error, e.g. from a failing lookup, results in 2400.
+type Authenticator interface {
+	Authenticate(ctx context.Context, clientID, password string, cert *x509.Certificate) error
+}
+
+// AuthenticatorFunc is a function implementing Authenticator.
+type AuthenticatorFunc func(ctx context.Context, clientID, password string, cert *x509.Certificate) error
+
+// Authenticate calls f.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, clientID, password string, cert *x509.Certificate) error {
+	return f(ctx, clientID, password, cert)
+}
+
+// PasswordChanger can be implemented by an Authenticator to support changing
+// the password with newPW at login. ChangePassword is called after the client
+// has been authenticated.
+type PasswordChanger interface {
+	ChangePassword(ctx context.Context, clientID, newPassword string) error
+}
+
+// ParseLogin parses a login command. An unsupported version is a 2100 error.
+func ParseLogin(doc *etree.Document) (*Login, error) {
+	ns := NamespaceIETFEPP10.String()
+
+	el := doc.FindElement(NewXMLPathBuilder().
+		Add("epp", ns).
+		Add("command", ns).
+		Add("login", ns).String())
+	if el == nil {
+		return nil, NewError(StatusCommandSyntaxError)
+	}
+
+	clientID, err := requiredChildText(el, "clID", ns)
+	if err != nil {
+		return nil, err
+	}
+
+	if n := utf8.RuneCountInString(clientID); n < 3 || n > 16 {
+		return nil, syntaxError("clID", ns, clientID)
+	}
+
+	login := &Login{ClientID: clientID}
+
+	// The passwords aren't trimmed since whitespace is allowed and they
+	// aren't included in errors.
+	pw := childElement(el, "pw", ns)
+	if pw == nil {
+		return nil, missingParameterError("pw", ns)
+	}
+
+	if login.Password, err = parseLoginPassword(pw); err != nil {
+		return nil, err
+	}
+
+	if newPW := childElement(el, "newPW", ns); newPW != nil {
+		if login.NewPassword, err = parseLoginPassword(newPW); err != nil {
+			return nil, err
+		}
+	}
+
+	options := childElement(el, "options", ns)
+	if options == nil {
+		return nil, missingParameterError("options", ns)
+	}
+
+	if login.Version, err = requiredChildText(options, "version", ns); err != nil {
+		return nil, err
+	}
+
+	if login.Version != "1.0" {
+		return nil, NewError(StatusUnimplementedProtocolVersion).WithValues(Value{
+			Element:   "version",
+			Value:     login.Version,
+			Namespace: ns,
+		})
+	}
+
+	if login.Lang, err = requiredChildText(options, "lang", ns); err != nil {
+		return nil, err
+	}
+
+	svcs := childElement(el, "svcs", ns)
+	if svcs == nil {
+		return nil, missingParameterError("svcs", ns)
+	}
+
+	login.ObjURIs = childTexts(svcs, "objURI", ns)
+	if len(login.ObjURIs) == 0 {
+		return nil, missingParameterError("objURI", ns)
+	}
+
+	if svcExtension := childElement(svcs, "svcExtension", ns); svcExtension != nil {
+		login.ExtURIs = childTexts(svcExtension, "extURI", ns)
+	}
+
+	return login, nil
+}
+
+// parseLoginPassword returns the text of a pw or newPW element which must be 6
+// to 16 characters.
+func parseLoginPassword(el *etree.Element) (string, error) {
+	password := el.Text()
+
+	if n := utf8.RuneCountInString(password); n < 6 || n > 16 {
+		return "", NewError(StatusValueSyntaxError).WithValues(Value{
+			Element:   el.Tag,
+			Namespace: NamespaceIETFEPP10.String(),
+		})
+	}
+
+	return password, nil
+}
@@ -0,0 +1,118 @@
+package epplib
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loginCommand(clID, pw, extra, version, svcs string) string {
+	return `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><login>` +
+		`<clID>` + clID + `</clID><pw>` + pw + `</pw>` + extra +
+		`<options><version>` + version + `</version><lang>en</lang></options>` +
+		`<svcs>` + svcs + `</svcs>` +
+		`</login><clTRID>ABC-12345</clTRID></command></epp>`
+}
+
+func TestParseLogin(t *testing.T) {
+	t.Parallel()
+
+	const svcs = `<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI><objURI>urn:ietf:params:xml:ns:host-1.0</objURI>` +
+		`<svcExtension><extURI>urn:ietf:params:xml:ns:secDNS-1.1</extURI></svcExtension>`
+
+	for _, tc := range []struct {
+		name          string
+		command       string
+		expected      *Login
+		expectedCode  int
+		expectedValue Value
+	}{
+		{
+			name:    "login",
+			command: loginCommand("ClientX", "foo-BAR2", `<newPW>bar-FOO2</newPW>`, "1.0", svcs),
+			expected: &Login{
+				ClientID:    "ClientX",
+				Password:    "foo-BAR2",
+				NewPassword: "bar-FOO2",
+				Version:     "1.0",
+				Lang:        "en",
+				ObjURIs:     []string{"urn:ietf:params:xml:ns:domain-1.0", "urn:ietf:params:xml:ns:host-1.0"},
+				ExtURIs:     []string{"urn:ietf:params:xml:ns:secDNS-1.1"},
+			},
+		},
+		{
+			name:    "without extensions",
+			command: loginCommand("ClientX", "foo-BAR2", "", "1.0", `<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>`),
+			expected: &Login{
+				ClientID: "ClientX",
+				Password: "foo-BAR2",
+				Version:  "1.0",
+				Lang:     "en",
+				ObjURIs:  []string{"urn:ietf:params:xml:ns:domain-1.0"},
+			},
+		},
+		{
+			name:         "not a login",
+			command:      `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><logout/></command></epp>`,
+			expectedCode: StatusCommandSyntaxError,
+		},
+		{
+			name:          "unsupported version",
+			command:       loginCommand("ClientX", "foo-BAR2", "", "2.0", svcs),
+			expectedCode:  StatusUnimplementedProtocolVersion,
+			expectedValue: Value{Element: "version", Value: "2.0", Namespace: NamespaceIETFEPP10.String()},
+		},
+		{
+			name:          "short client id",
+			command:       loginCommand("CX", "foo-BAR2", "", "1.0", svcs),
+			expectedCode:  StatusValueSyntaxError,
+			expectedValue: Value{Element: "clID", Value: "CX", Namespace: NamespaceIETFEPP10.String()},
+		},
+		{
+			name:          "short password",
+			command:       loginCommand("ClientX", "foo", "", "1.0", svcs),
+			expectedCode:  StatusValueSyntaxError,
+			expectedValue: Value{Element: "pw", Namespace: NamespaceIETFEPP10.String()},
+		},
+		{
+			name:          "long new password",
+			command:       loginCommand("ClientX", "foo-BAR2", `<newPW>0123456789abcdefg</newPW>`, "1.0", svcs),
+			expectedCode:  StatusValueSyntaxError,
+			expectedValue: Value{Element: "newPW", Namespace: NamespaceIETFEPP10.String()},
+		},
+		{
+			name:          "no object services",
+			command:       loginCommand("ClientX", "foo-BAR2", "", "1.0", ""),
+			expectedCode:  StatusMissingParameter,
+			expectedValue: Value{Element: "objURI", Namespace: NamespaceIETFEPP10.String()},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromString(tc.command))
+
+			login, err := ParseLogin(doc)
+
+			if tc.expectedCode != 0 {
+				var eppErr *EppError
+
+				require.True(t, errors.As(err, &eppErr))
+				assert.Equal(t, tc.expectedCode, eppErr.Code)
+
+				if tc.expectedValue.Element != "" {
+					assert.Equal(t, []Value{tc.expectedValue}, eppErr.Values)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, login)
+		})
+	}
+}
@@ -2,16 +2,86 @@ package epplib
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"io"
 	"log/slog"
+	"regexp"
+	"slices"
 
 	"github.com/beevik/etree"
 )
 
+// pathNamespaceRegexp matches the namespace filters in a path.
+var pathNamespaceRegexp = regexp.MustCompile(`namespace-uri\(\)='([^']*)'`)
+
 // CommandMux parses and routes xml commands to bound handlers.
 type CommandMux struct {
+	// AutoGreetingServices adds the object and extension namespaces used by
+	// the bound handlers to the objURI and extURI lists of a greeting bound
+	// with BindGreetingBuilder.
+	AutoGreetingServices bool
+
+	// ErrorResponses makes Handle reply with an EPP error response instead of
+	// only closing the connection when a command can't be handled. Commands
+	// that can't be parsed get 2001, unknown commands 2000, known commands
+	// that aren't bound 2101 and commands for objects that aren't bound 2307.
+	ErrorResponses bool
+
+	// KeepSessionOnError keeps the connection open after an error response
+	// written because of ErrorResponses.
+	KeepSessionOnError bool
+
+	// RequireLogin makes Handle reply 2002 to every command except hello and
+	// login until the Session from the context is logged in, and 2002 to login
+	// commands after that. Logout is handled by the CommandMux by logging out
+	// the session, replying 1500 and closing the connection. Documents that
+	// aren't exactly one hello or one command in an epp element get 2001.
+	RequireLogin bool
+
+	// EnforceServices makes Handle reply 2307 to commands for objects, and 2103
+	// to commands with extensions, that weren't part of the services chosen at
+	// login by a logged in Session. Every object element of a command is
+	// checked. Documents that aren't exactly one hello or one command in an
+	// epp element get 2001.
+	EnforceServices bool
+
+	// ServerTransactionID returns the svTRID for responses written by the
+	// CommandMux. NewServerTransactionID is used if not set.
+	ServerTransactionID func(ctx context.Context) string
+
+	// Authenticator makes the CommandMux handle login commands instead of a
+	// bound handler. The client is authenticated with the Authenticator and
+	// the Session from the context is logged in with the services negotiated
+	// against Services. Logins with a language that isn't in Langs get 2102,
+	// logins requesting a service without a supported version get 2307 and
+	// failed logins get 2200. Middleware added with Use is applied to the
+	// login.
+	Authenticator Authenticator
+
+	// Services are the object and extension namespaces supported at login
+	// when the CommandMux handles logins. Defaults to the namespaces of the
+	// bound handlers.
+	Services Namespaces
+
+	// Langs are the languages accepted at login when the CommandMux handles
+	// logins. Defaults to "en".
+	Langs []string
+
+	// CertificatePolicy if set verifies that the client certificate of the
+	// connection belongs to the client at login. Mismatches are handled as
+	// failed logins. Without an Authenticator it is verified before the bound
+	// login handler is called. When set, documents that aren't exactly one
+	// hello or one command in an epp element get 2001.
+	CertificatePolicy CertificatePolicy
+
+	// MaxLoginAttempts is the number of failed logins after which the
+	// CommandMux replies 2501 and closes the connection. Zero means no limit.
+	MaxLoginAttempts int
+
 	greetingCommand CommandFunc
 	handlers        []handler
+	middleware      []Middleware
 }
 
 // GetGreeting returns a greeting.
@@ -30,20 +100,421 @@ func (c *CommandMux) Handle(ctx context.Context, rw *ResponseWriter, cmd io.Read
 			slog.Any("err", err),
 		)
 
-		rw.CloseAfterWrite()
+		// Make sure the rest of the message isn't read as the next one.
+		_, _ = io.Copy(io.Discard, cmd)
+
+		c.writeError(ctx, rw, "", NewError(StatusCommandSyntaxError))
+
+		return
+	}
+
+	info := ParseCommandInfo(doc)
+
+	if info.Name == "" && (c.RequireLogin || c.EnforceServices || c.CertificatePolicy != nil) {
+		slog.InfoContext(ctx, "invalid command document")
+		c.writeResponse(ctx, rw, "", NewError(StatusCommandSyntaxError))
+
+		return
+	}
+
+	if c.RequireLogin && c.handleSession(ctx, rw, info) {
+		return
+	}
+
+	if c.EnforceServices && c.handleServices(ctx, rw, info) {
+		return
+	}
+
+	if c.Authenticator != nil && info.Name == "login" {
+		login := handler{fn: c.handleLogin}
+		login.commandFunc(c.middleware)(ctx, rw, doc)
 
 		return
 	}
 
 	for _, h := range c.handlers {
 		if el := doc.FindElementPath(h.path); el != nil {
-			h.fn(ctx, rw, doc)
+			if info.Name == "login" && c.CertificatePolicy != nil {
+				h.fn = c.verifyLoginCertificate(h.fn)
+			}
+
+			h.commandFunc(c.middleware)(ctx, rw, doc)
 			return
 		}
 	}
 
 	slog.InfoContext(ctx, "unknown command")
-	rw.CloseAfterWrite()
+
+	c.writeError(ctx, rw, info.ClientTransactionID, c.unhandledCommandError(info))
+}
+
+// handleSession enforces the login state of the session and handles logout.
+// It reports whether a response has been written.
+func (c *CommandMux) handleSession(ctx context.Context, rw Writer, info CommandInfo) bool {
+	if info.Name == "" || info.Name == "hello" {
+		return false
+	}
+
+	session := SessionFromContext(ctx)
+	loggedIn := session != nil && session.LoggedIn()
+
+	switch {
+	case info.Name == "login" && !loggedIn:
+		return false
+	case info.Name == "logout" && loggedIn:
+		session.Logout()
+
+		c.writeResponse(ctx, rw, info.ClientTransactionID, NewError(StatusEndingSession))
+		rw.CloseAfterWrite()
+
+		return true
+	case info.Name == "login" || !loggedIn:
+		c.writeResponse(ctx, rw, info.ClientTransactionID, NewError(StatusCommandUseError))
+		return true
+	}
+
+	return false
+}
+
+// handleServices verifies that the command only uses services chosen at login.
+// It reports whether a response has been written.
+func (c *CommandMux) handleServices(ctx context.Context, rw Writer, info CommandInfo) bool {
+	session := SessionFromContext(ctx)
+	if session == nil || !session.LoggedIn() {
+		return false
+	}
+
+	for _, obj := range info.Objects {
+		if !hasNamespaceURI(session.ObjectNamespaces(), obj) {
+			c.writeResponse(ctx, rw, info.ClientTransactionID, NewError(StatusUnimplementedObjectService))
+			return true
+		}
+	}
+
+	for _, ext := range info.Extensions {
+		if !hasNamespaceURI(session.ExtensionNamespaces(), ext) {
+			c.writeResponse(ctx, rw, info.ClientTransactionID, NewError(StatusUnimplementedExtension))
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleLogin authenticates the client of a login command with the
+// Authenticator and logs in the Session from the context.
+func (c *CommandMux) handleLogin(ctx context.Context, rw Writer, doc *etree.Document) {
+	clTRID := ParseCommandInfo(doc).ClientTransactionID
+
+	login, err := ParseLogin(doc)
+	if err != nil {
+		c.writeResponse(ctx, rw, clTRID, asEppError(err, StatusCommandSyntaxError))
+		return
+	}
+
+	session := SessionFromContext(ctx)
+	if session == nil {
+		slog.ErrorContext(ctx, "login without session")
+		c.writeResponse(ctx, rw, clTRID, NewError(StatusCommandFailed))
+
+		return
+	}
+
+	if session.LoggedIn() {
+		c.writeResponse(ctx, rw, clTRID, NewError(StatusCommandUseError))
+		return
+	}
+
+	changer, canChangePassword := c.Authenticator.(PasswordChanger)
+	if login.NewPassword != "" && !canChangePassword {
+		c.writeResponse(ctx, rw, clTRID, NewError(StatusUnimplementedOption).WithValues(Value{
+			Element:   "newPW",
+			Namespace: NamespaceIETFEPP10.String(),
+		}))
+
+		return
+	}
+
+	objects := c.supportedNamespaces(Namespace.IsObjectNamespace)
+	extensions := c.supportedNamespaces(Namespace.IsExtensionNamespace)
+
+	if eppErr := c.verifyLoginOptions(login, objects, extensions); eppErr != nil {
+		c.writeResponse(ctx, rw, clTRID, eppErr)
+		return
+	}
+
+	var cert *x509.Certificate
+
+	if certs := session.ConnectionState().PeerCertificates; len(certs) > 0 {
+		cert = certs[0]
+	}
+
+	if err := c.Authenticator.Authenticate(ctx, login.ClientID, login.Password, cert); err != nil {
+		c.loginError(ctx, rw, session, login.ClientID, clTRID, err)
+		return
+	}
+
+	if c.CertificatePolicy != nil && !c.verifyCertificate(ctx, rw, session, login.ClientID, clTRID) {
+		return
+	}
+
+	// The session is logged in before the password is changed so that a
+	// login refused by the SessionLimit doesn't change the password.
+	if err := session.LoginNegotiated(login, append(objects, extensions...)); err != nil {
+		if errors.Is(err, ErrSessionLimitExceeded) {
+			c.writeResponse(ctx, rw, clTRID, NewError(StatusSessionLimitExceededClosingConnection))
+			rw.CloseAfterWrite()
+
+			return
+		}
+
+		c.writeResponse(ctx, rw, clTRID, NewError(StatusCommandUseError))
+
+		return
+	}
+
+	if login.NewPassword != "" {
+		if err := changer.ChangePassword(ctx, login.ClientID, login.NewPassword); err != nil {
+			slog.ErrorContext(ctx, "could not change password",
+				slog.String("client_id", login.ClientID),
+				slog.Any("err", err),
+			)
+
+			session.Logout()
+
+			c.writeResponse(ctx, rw, clTRID, asEppError(err, StatusCommandFailed))
+
+			return
+		}
+	}
+
+	c.writeResponse(ctx, rw, clTRID, NewError(StatusSuccess))
+}
+
+// verifyLoginOptions returns 2102 if the language of the login isn't one of
+// Langs and 2307 if the login requests an object or extension service without
+// a version that both sides support.
+func (c *CommandMux) verifyLoginOptions(login *Login, objects, extensions Namespaces) *EppError {
+	ns := NamespaceIETFEPP10.String()
+
+	langs := c.Langs
+	if len(langs) == 0 {
+		langs = []string{"en"}
+	}
+
+	if !slices.Contains(langs, login.Lang) {
+		return NewError(StatusUnimplementedOption).WithValues(Value{
+			Element:   "lang",
+			Value:     login.Lang,
+			Namespace: ns,
+		})
+	}
+
+	negotiated := NegotiateNamespaces(login.ObjURIs, objects)
+
+	for _, uri := range login.ObjURIs {
+		if !hasNamespaceFamily(negotiated, uri) {
+			return NewError(StatusUnimplementedObjectService).WithValues(Value{
+				Element:   "objURI",
+				Value:     uri,
+				Namespace: ns,
+			})
+		}
+	}
+
+	negotiated = NegotiateNamespaces(login.ExtURIs, extensions)
+
+	for _, uri := range login.ExtURIs {
+		if !hasNamespaceFamily(negotiated, uri) {
+			return NewError(StatusUnimplementedObjectService).WithValues(Value{
+				Element:   "extURI",
+				Value:     uri,
+				Namespace: ns,
+			})
+		}
+	}
+
+	return nil
+}
+
+// verifyLoginCertificate wraps a bound login handler to verify the client
+// certificate with the CertificatePolicy before it is called.
+func (c *CommandMux) verifyLoginCertificate(next CommandFunc) CommandFunc {
+	return func(ctx context.Context, rw Writer, doc *etree.Document) {
+		clTRID := ParseCommandInfo(doc).ClientTransactionID
+
+		login, err := ParseLogin(doc)
+		if err != nil {
+			c.writeResponse(ctx, rw, clTRID, asEppError(err, StatusCommandSyntaxError))
+			return
+		}
+
+		session := SessionFromContext(ctx)
+		if session == nil {
+			slog.ErrorContext(ctx, "login without session")
+			c.writeResponse(ctx, rw, clTRID, NewError(StatusCommandFailed))
+
+			return
+		}
+
+		if !c.verifyCertificate(ctx, rw, session, login.ClientID, clTRID) {
+			return
+		}
+
+		next(ctx, rw, doc)
+	}
+}
+
+// verifyCertificate verifies the client certificate of the session with the
+// CertificatePolicy. It reports whether the certificate belongs to the client,
+// otherwise a response has been written.
+func (c *CommandMux) verifyCertificate(ctx context.Context, rw Writer, session *Session, clientID, clTRID string) bool {
+	err := c.CertificatePolicy.VerifyCertificate(ctx, clientID, session.ConnectionState())
+	if err != nil {
+		c.loginError(ctx, rw, session, clientID, clTRID, err)
+		return false
+	}
+
+	return true
+}
+
+// loginError replies to a login refused by the Authenticator or the
+// CertificatePolicy. Errors that aren't failed logins, e.g. from a failing
+// lookup, get 2400 and don't count as failed logins.
+func (c *CommandMux) loginError(ctx context.Context, rw Writer, session *Session, clientID, clTRID string, err error) {
+	var eppErr *EppError
+
+	if errors.As(err, &eppErr) || errors.Is(err, ErrAuthenticationFailed) || errors.Is(err, ErrCertificateMismatch) {
+		c.loginFailed(ctx, rw, session, clientID, clTRID, err)
+		return
+	}
+
+	slog.ErrorContext(ctx, "could not verify login",
+		slog.String("client_id", clientID),
+		slog.Any("err", err),
+	)
+
+	c.writeResponse(ctx, rw, clTRID, NewError(StatusCommandFailed))
+}
+
+// loginFailed replies to a failed login with 2200, or the *EppError returned
+// by the Authenticator, and with 2501 and closes the connection when
+// MaxLoginAttempts is reached.
+func (c *CommandMux) loginFailed(ctx context.Context, rw Writer, session *Session, clientID, clTRID string, err error) {
+	attempts := session.loginFailed()
+
+	slog.InfoContext(ctx, "login failed",
+		slog.String("client_id", clientID),
+		slog.Int("attempts", attempts),
+		slog.Any("err", err),
+	)
+
+	if c.MaxLoginAttempts > 0 && attempts >= c.MaxLoginAttempts {
+		c.writeResponse(ctx, rw, clTRID, NewError(StatusAuthenticationErrorClosingConnection))
+		rw.CloseAfterWrite()
+
+		return
+	}
+
+	c.writeResponse(ctx, rw, clTRID, asEppError(err, StatusAuthenticationError))
+}
+
+// supportedNamespaces returns the namespaces of Services, or of the bound
+// handlers if Services is empty, matching the filter.
+func (c *CommandMux) supportedNamespaces(filter func(Namespace) bool) Namespaces {
+	var namespaces Namespaces
+
+	if len(c.Services) > 0 {
+		for _, ns := range c.Services {
+			if filter(ns) {
+				namespaces = append(namespaces, ns)
+			}
+		}
+
+		return namespaces
+	}
+
+	for _, ns := range c.namespaces(filter) {
+		namespaces = append(namespaces, NamespaceFromString(ns))
+	}
+
+	return namespaces
+}
+
+// hasNamespaceURI reports if the namespace uri is a known namespace in ns.
+func hasNamespaceURI(ns Namespaces, uri string) bool {
+	n := NamespaceFromString(uri)
+
+	return n != NamespaceUnknown && ns.HasNamespace(n)
+}
+
+// hasNamespaceFamily reports if ns has a version of the family of the
+// namespace uri.
+func hasNamespaceFamily(ns Namespaces, uri string) bool {
+	family, _ := splitNamespaceVersion(uri)
+	if info, ok := NamespaceFromString(uri).Info(); ok {
+		family = info.Family
+	}
+
+	for _, n := range ns {
+		if info, ok := n.Info(); ok && info.Family == family {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unhandledCommandError returns the error for a command without a handler.
+func (c *CommandMux) unhandledCommandError(info CommandInfo) *EppError {
+	switch {
+	case info.Name == "":
+		return NewError(StatusCommandSyntaxError)
+	case !info.IsKnownCommand():
+		return NewError(StatusUnknownCommand)
+	case info.Namespace != "" && !c.hasNamespace(info.Namespace):
+		return NewError(StatusUnimplementedObjectService)
+	default:
+		return NewError(StatusUnimplementedCommand)
+	}
+}
+
+// writeError writes an error response if ErrorResponses is set and closes the
+// connection unless KeepSessionOnError is set.
+func (c *CommandMux) writeError(ctx context.Context, rw Writer, clTRID string, eppErr *EppError) {
+	if !c.ErrorResponses {
+		rw.CloseAfterWrite()
+		return
+	}
+
+	c.writeResponse(ctx, rw, clTRID, eppErr)
+
+	if !c.KeepSessionOnError {
+		rw.CloseAfterWrite()
+	}
+}
+
+// writeResponse replaces anything written on rw with a response containing the
+// result. The connection is closed if the response can't be written.
+func (c *CommandMux) writeResponse(ctx context.Context, rw Writer, clTRID string, result *EppError) {
+	rw.Reset()
+
+	if err := WriteErrorResponse(rw, clTRID, c.serverTransactionID(ctx), result); err != nil {
+		slog.ErrorContext(ctx, "could not write response",
+			slog.Any("err", err),
+		)
+
+		rw.Reset()
+		rw.CloseAfterWrite()
+	}
+}
+
+func (c *CommandMux) serverTransactionID(ctx context.Context) string {
+	if c.ServerTransactionID != nil {
+		return c.ServerTransactionID(ctx)
+	}
+
+	return NewServerTransactionID()
 }
 
 // BindGreeting bind a greeting handler. Useful because EPP needs to send a
@@ -52,26 +523,134 @@ func (c *CommandMux) BindGreeting(handler CommandFunc) {
 	c.greetingCommand = handler
 }
 
-// Bind will bind a handler to a path.
-func (c *CommandMux) Bind(path string, handlerFunc CommandFunc) {
+// BindGreetingBuilder bind a greeting handler that writes the greeting built by
+// g. The objURI and extURI lists are extended with the namespaces of the bound
+// handlers if AutoGreetingServices is set.
+func (c *CommandMux) BindGreetingBuilder(g *GreetingBuilder) {
+	c.BindGreeting(func(ctx context.Context, rw Writer, _ *etree.Document) {
+		greeting := *g
+
+		if c.AutoGreetingServices {
+			greeting.ObjURIs = appendMissing(slices.Clone(greeting.ObjURIs), c.namespaces(Namespace.IsObjectNamespace)...)
+			greeting.ExtURIs = appendMissing(slices.Clone(greeting.ExtURIs), c.namespaces(Namespace.IsExtensionNamespace)...)
+		}
+
+		if _, err := greeting.WriteTo(rw); err != nil {
+			slog.ErrorContext(ctx, "could not write greeting",
+				slog.Any("err", err),
+			)
+		}
+	})
+}
+
+// Use adds middleware that is applied to the handlers of every route, in the
+// order they are added. Middleware added with Use wraps the route middleware.
+func (c *CommandMux) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// Bind will bind a handler to a path. The middleware is only applied to this
+// handler, in the order given.
+func (c *CommandMux) Bind(path string, handlerFunc CommandFunc, middleware ...Middleware) {
 	if c.handlers == nil {
 		c.handlers = make([]handler, 0, 1)
 	}
 
-	c.handlers = append(c.handlers, handler{
-		fn:   handlerFunc,
-		path: etree.MustCompilePath(path),
-	})
+	h := handler{
+		fn:         handlerFunc,
+		path:       etree.MustCompilePath(path),
+		middleware: middleware,
+	}
+
+	for _, match := range pathNamespaceRegexp.FindAllStringSubmatch(path, -1) {
+		h.namespaces = appendMissing(h.namespaces, match[1])
+	}
+
+	c.handlers = append(c.handlers, h)
 }
 
 // BindCommand is a convenience method wrapping `Bind` with the common pattern used in
 // epp. Note that it's currently hardcoded in the namespace-uri versions since there is
 // currently only one.
-func (c *CommandMux) BindCommand(command, ns string, handlerFunc CommandFunc) {
+func (c *CommandMux) BindCommand(command, ns string, handlerFunc CommandFunc, middleware ...Middleware) {
 	c.Bind(NewXMLPathBuilder().
-		AddOrphan("//command", "urn:ietf:params:xml:ns:epp-1.0").
+		Add("epp", "urn:ietf:params:xml:ns:epp-1.0").
+		Add("command", "urn:ietf:params:xml:ns:epp-1.0").
 		Add(command, "urn:ietf:params:xml:ns:epp-1.0").
 		Add(command, ns).String(),
 		handlerFunc,
+		middleware...,
 	)
 }
+
+// BindCommandExtension is like `BindCommand` but only matches commands that also
+// carry the extension element in the extNs namespace. Since handlers are
+// matched in the order they are bound it should be bound before the
+// corresponding `BindCommand`.
+func (c *CommandMux) BindCommandExtension(
+	command, ns, extension, extNs string,
+	handlerFunc CommandFunc,
+	middleware ...Middleware,
+) {
+	c.Bind(NewXMLPathBuilder().
+		Add("epp", "urn:ietf:params:xml:ns:epp-1.0").
+		Add("command", "urn:ietf:params:xml:ns:epp-1.0").
+		Add("extension", "urn:ietf:params:xml:ns:epp-1.0").
+		Add(extension, extNs).
+		Add("..", "").
+		Add("..", "").
+		Add(command, "urn:ietf:params:xml:ns:epp-1.0").
+		Add(command, ns).String(),
+		handlerFunc,
+		middleware...,
+	)
+}
+
+// namespaces returns the namespaces of the bound handlers matching the filter.
+func (c *CommandMux) namespaces(filter func(Namespace) bool) []string {
+	var namespaces []string
+
+	for _, h := range c.handlers {
+		for _, ns := range h.namespaces {
+			if filter(NamespaceFromString(ns)) {
+				namespaces = appendMissing(namespaces, ns)
+			}
+		}
+	}
+
+	return namespaces
+}
+
+// hasNamespace reports if any bound handler uses the namespace.
+func (c *CommandMux) hasNamespace(ns string) bool {
+	for _, h := range c.handlers {
+		if slices.Contains(h.namespaces, ns) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendMissing appends the values that are not already in the slice.
+func appendMissing(slice []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(slice, v) {
+			slice = append(slice, v)
+		}
+	}
+
+	return slice
+}
+
+// asEppError returns err as an *EppError or a new error with the code if it
+// isn't one.
+func asEppError(err error, code int) *EppError {
+	var eppErr *EppError
+
+	if errors.As(err, &eppErr) {
+		return eppErr
+	}
+
+	return NewError(code)
+}
@@ -2,11 +2,16 @@ package epplib
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/beevik/etree"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMux_Greeting(t *testing.T) {
@@ -86,6 +91,600 @@ func TestMux_Handle(t *testing.T) {
 	}
 }
 
+func TestMux_HandleErrorResponses(t *testing.T) {
+	t.Parallel()
+
+	cm := &CommandMux{
+		ErrorResponses: true,
+		ServerTransactionID: func(context.Context) string {
+			return "SV-1"
+		},
+	}
+
+	cm.BindCommand("info", NamespaceIETFDomain10.String(), func(context.Context, Writer, *etree.Document) {})
+
+	for _, tc := range []struct {
+		name               string
+		keepSession        bool
+		command            string
+		expectCode         string
+		expectClTRID       string
+		expectRwCloseAfter bool
+	}{
+		{
+			name:               "unparsable command",
+			command:            "<epp><command>",
+			expectCode:         "2001",
+			expectRwCloseAfter: true,
+		},
+		{
+			name: "command in foreign root",
+			command: `<x><command xmlns="urn:ietf:params:xml:ns:epp-1.0"><info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+</info></command></x>`,
+			expectCode:         "2001",
+			expectRwCloseAfter: true,
+		},
+		{
+			name:               "unknown command",
+			command:            `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><foo/><clTRID>ABC-1</clTRID></command></epp>`,
+			expectCode:         "2000",
+			expectClTRID:       "ABC-1",
+			expectRwCloseAfter: true,
+		},
+		{
+			name:         "unimplemented command",
+			keepSession:  true,
+			command:      `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><poll op="req"/><clTRID>ABC-2</clTRID></command></epp>`,
+			expectCode:   "2101",
+			expectClTRID: "ABC-2",
+		},
+		{
+			name:        "unimplemented command for bound object",
+			keepSession: true,
+			command: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><check>
+<domain:check xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:check>
+</check></command></epp>`,
+			expectCode: "2101",
+		},
+		{
+			name:        "unimplemented object",
+			keepSession: true,
+			command: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<host:info xmlns:host="urn:ietf:params:xml:ns:host-1.0"><host:name>ns1.example.se</host:name></host:info>
+</info></command></epp>`,
+			expectCode: "2307",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := *cm
+			cm.KeepSessionOnError = tc.keepSession
+
+			rw := &ResponseWriter{}
+			cm.Handle(context.Background(), rw, strings.NewReader(tc.command))
+
+			assert.Equal(t, tc.expectRwCloseAfter, rw.ShouldCloseAfterWrite())
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+
+			assert.Equal(t, tc.expectCode, doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+			assert.Equal(t, tc.expectClTRID, doc.FindElement("/epp/response/trID/clTRID").NotNil().Text())
+			assert.Equal(t, "SV-1", doc.FindElement("/epp/response/trID/svTRID").Text())
+		})
+	}
+}
+
+func TestMux_RequireLogin(t *testing.T) {
+	t.Parallel()
+
+	var handled []string
+
+	cm := &CommandMux{
+		RequireLogin: true,
+		ServerTransactionID: func(context.Context) string {
+			return "SV-1"
+		},
+	}
+
+	cm.Bind(NewXMLPathBuilder().AddOrphan("//hello", NamespaceIETFEPP10.String()).String(),
+		func(context.Context, Writer, *etree.Document) {
+			handled = append(handled, "hello")
+		},
+	)
+	cm.Bind(NewXMLPathBuilder().AddOrphan("//command", NamespaceIETFEPP10.String()).Add("login", NamespaceIETFEPP10.String()).String(),
+		func(ctx context.Context, _ Writer, _ *etree.Document) {
+			handled = append(handled, "login")
+			require.NoError(t, SessionFromContext(ctx).Login("ClientX", "en", nil, nil))
+		},
+	)
+	cm.BindCommand("info", NamespaceIETFDomain10.String(), func(context.Context, Writer, *etree.Document) {
+		handled = append(handled, "info")
+	})
+
+	const (
+		hello  = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><hello/></epp>`
+		login  = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><login><clID>ClientX</clID></login><clTRID>ABC-1</clTRID></command></epp>`
+		logout = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><logout/><clTRID>ABC-2</clTRID></command></epp>`
+		info   = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+</info></command></epp>`
+		foreignRoot = `<x><command xmlns="urn:ietf:params:xml:ns:epp-1.0"><info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+</info></command></x>`
+		trailingHello = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+</info></command><hello/></epp>`
+	)
+
+	session := &Session{}
+	ctx := ContextWithSession(context.Background(), session)
+
+	for _, step := range []struct {
+		command            string
+		expectCode         string
+		expectHandled      []string
+		expectRwCloseAfter bool
+	}{
+		{command: hello, expectHandled: []string{"hello"}},
+		{command: info, expectCode: "2002", expectHandled: []string{"hello"}},
+		{command: foreignRoot, expectCode: "2001", expectHandled: []string{"hello"}},
+		{command: trailingHello, expectCode: "2001", expectHandled: []string{"hello"}},
+		{command: logout, expectCode: "2002", expectHandled: []string{"hello"}},
+		{command: login, expectHandled: []string{"hello", "login"}},
+		{command: login, expectCode: "2002", expectHandled: []string{"hello", "login"}},
+		{command: info, expectHandled: []string{"hello", "login", "info"}},
+		{command: logout, expectCode: "1500", expectHandled: []string{"hello", "login", "info"}, expectRwCloseAfter: true},
+	} {
+		rw := &ResponseWriter{}
+		cm.Handle(ctx, rw, strings.NewReader(step.command))
+
+		assert.Equal(t, step.expectHandled, handled)
+		assert.Equal(t, step.expectRwCloseAfter, rw.ShouldCloseAfterWrite())
+
+		if step.expectCode == "" {
+			assert.Zero(t, rw.Len())
+			continue
+		}
+
+		doc := etree.NewDocument()
+		require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+		assert.Equal(t, step.expectCode, doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+	}
+
+	assert.False(t, session.LoggedIn())
+}
+
+type testPasswordChanger struct {
+	AuthenticatorFunc
+
+	newPassword string
+}
+
+func (c *testPasswordChanger) ChangePassword(_ context.Context, _, newPassword string) error {
+	c.newPassword = newPassword
+	return nil
+}
+
+func TestMux_Authenticator(t *testing.T) {
+	t.Parallel()
+
+	authenticate := AuthenticatorFunc(func(_ context.Context, clientID, password string, cert *x509.Certificate) error {
+		if password == "backend-ERR" {
+			return errors.New("connection refused")
+		}
+
+		if clientID != "ClientX" || password != "foo-BAR2" {
+			return ErrAuthenticationFailed
+		}
+
+		if cert != nil {
+			return NewError(StatusAuthorizationError)
+		}
+
+		return nil
+	})
+
+	const (
+		svcs = `<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>` +
+			`<svcExtension><extURI>urn:ietf:params:xml:ns:secDNS-1.1</extURI></svcExtension>`
+		hostSvcs = `<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI><objURI>urn:ietf:params:xml:ns:host-1.0</objURI>`
+		extSvcs  = `<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>` +
+			`<svcExtension><extURI>urn:ietf:params:xml:ns:secDNS-1.0</extURI><extURI>urn:ietf:params:xml:ns:secDNS-1.1</extURI></svcExtension>`
+		oldExtSvcs = `<objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>` +
+			`<svcExtension><extURI>urn:ietf:params:xml:ns:secDNS-1.0</extURI></svcExtension>`
+	)
+
+	svLogin := strings.Replace(loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs), "<lang>en</lang>", "<lang>sv</lang>", 1)
+
+	for _, tc := range []struct {
+		name              string
+		authenticator     Authenticator
+		langs             []string
+		services          Namespaces
+		rateLimit         bool
+		cert              *x509.Certificate
+		commands          []string
+		expectCodes       []string
+		expectClose       bool
+		sessionFull       bool
+		expectLoggedIn    bool
+		expectNewPassword string
+	}{
+		{
+			name:           "success",
+			authenticator:  authenticate,
+			commands:       []string{loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs)},
+			expectCodes:    []string{"1000"},
+			expectLoggedIn: true,
+		},
+		{
+			name:          "wrong password",
+			authenticator: authenticate,
+			commands:      []string{loginCommand("ClientX", "bar-FOO2", "", "1.0", svcs)},
+			expectCodes:   []string{"2200"},
+		},
+		{
+			name:          "authenticator error code",
+			authenticator: authenticate,
+			cert:          &x509.Certificate{},
+			commands:      []string{loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs)},
+			expectCodes:   []string{"2201"},
+		},
+		{
+			name:          "too many attempts",
+			authenticator: authenticate,
+			commands: []string{
+				loginCommand("ClientX", "bar-FOO2", "", "1.0", svcs),
+				loginCommand("ClientX", "bar-FOO2", "", "1.0", svcs),
+				loginCommand("ClientX", "bar-FOO2", "", "1.0", svcs),
+			},
+			expectCodes: []string{"2200", "2200", "2501"},
+			expectClose: true,
+		},
+		{
+			name:          "backend error",
+			authenticator: authenticate,
+			commands: []string{
+				loginCommand("ClientX", "backend-ERR", "", "1.0", svcs),
+				loginCommand("ClientX", "backend-ERR", "", "1.0", svcs),
+				loginCommand("ClientX", "backend-ERR", "", "1.0", svcs),
+			},
+			expectCodes: []string{"2400", "2400", "2400"},
+		},
+		{
+			name:          "unsupported version",
+			authenticator: authenticate,
+			commands:      []string{loginCommand("ClientX", "foo-BAR2", "", "0.9", svcs)},
+			expectCodes:   []string{"2100"},
+		},
+		{
+			name:          "unsupported lang",
+			authenticator: authenticate,
+			commands:      []string{svLogin},
+			expectCodes:   []string{"2102"},
+		},
+		{
+			name:           "supported lang",
+			authenticator:  authenticate,
+			langs:          []string{"en", "sv"},
+			commands:       []string{svLogin},
+			expectCodes:    []string{"1000"},
+			expectLoggedIn: true,
+		},
+		{
+			name:          "unsupported object service",
+			authenticator: authenticate,
+			commands:      []string{loginCommand("ClientX", "foo-BAR2", "", "1.0", hostSvcs)},
+			expectCodes:   []string{"2307"},
+		},
+		{
+			name:           "several extension versions",
+			authenticator:  authenticate,
+			commands:       []string{loginCommand("ClientX", "foo-BAR2", "", "1.0", extSvcs)},
+			expectCodes:    []string{"1000"},
+			expectLoggedIn: true,
+		},
+		{
+			name:          "unsupported extension version",
+			authenticator: authenticate,
+			commands:      []string{loginCommand("ClientX", "foo-BAR2", "", "1.0", oldExtSvcs)},
+			expectCodes:   []string{"2307"},
+		},
+		{
+			name:           "services",
+			authenticator:  authenticate,
+			services:       Namespaces{NamespaceIETFDomain10, NamespaceIETFHost10, NamespaceIETFSecDNS10, NamespaceIETFSecDNS11},
+			commands:       []string{loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs)},
+			expectCodes:    []string{"1000"},
+			expectLoggedIn: true,
+		},
+		{
+			name:          "services without bound extension",
+			authenticator: authenticate,
+			services:      Namespaces{NamespaceIETFDomain10},
+			commands:      []string{loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs)},
+			expectCodes:   []string{"2307"},
+		},
+		{
+			name:          "rate limited",
+			authenticator: authenticate,
+			rateLimit:     true,
+			commands: []string{
+				loginCommand("ClientX", "bar-FOO2", "", "1.0", svcs),
+				loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs),
+			},
+			expectCodes: []string{"2200", "2400"},
+		},
+		{
+			name:          "new password not supported",
+			authenticator: authenticate,
+			commands:      []string{loginCommand("ClientX", "foo-BAR2", "<newPW>bar-FOO2</newPW>", "1.0", svcs)},
+			expectCodes:   []string{"2102"},
+		},
+		{
+			name:              "new password",
+			authenticator:     &testPasswordChanger{AuthenticatorFunc: authenticate},
+			commands:          []string{loginCommand("ClientX", "foo-BAR2", "<newPW>bar-FOO2</newPW>", "1.0", svcs)},
+			expectCodes:       []string{"1000"},
+			expectLoggedIn:    true,
+			expectNewPassword: "bar-FOO2",
+		},
+		{
+			name:          "session limit exceeded",
+			authenticator: &testPasswordChanger{AuthenticatorFunc: authenticate},
+			sessionFull:   true,
+			commands:      []string{loginCommand("ClientX", "foo-BAR2", "<newPW>bar-FOO2</newPW>", "1.0", svcs)},
+			expectCodes:   []string{"2502"},
+			expectClose:   true,
+		},
+		{
+			name:           "already logged in",
+			authenticator:  authenticate,
+			commands:       []string{loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs), loginCommand("ClientX", "foo-BAR2", "", "1.0", svcs)},
+			expectCodes:    []string{"1000", "2002"},
+			expectLoggedIn: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cm := &CommandMux{
+				Authenticator:    tc.authenticator,
+				Langs:            tc.langs,
+				Services:         tc.services,
+				MaxLoginAttempts: 3,
+			}
+
+			if tc.rateLimit {
+				cm.Use((&RateLimiter{
+					Key:    func(context.Context) string { return "127.0.0.1" },
+					Limits: map[string]RateLimit{"login": {Rate: 0.001, Burst: 1}},
+					Action: RateLimitReject,
+				}).Middleware)
+			}
+			cm.BindCommand("info", NamespaceIETFDomain10.String(), func(context.Context, Writer, *etree.Document) {})
+			cm.BindCommandExtension("create", NamespaceIETFDomain10.String(), "create", NamespaceIETFSecDNS11.String(),
+				func(context.Context, Writer, *etree.Document) {},
+			)
+
+			session := &Session{}
+			if tc.cert != nil {
+				session.connState.PeerCertificates = []*x509.Certificate{tc.cert}
+			}
+
+			if tc.sessionFull {
+				session.onLogin = func(string) error { return ErrSessionLimitExceeded }
+			}
+
+			ctx := ContextWithSession(context.Background(), session)
+
+			var rw *ResponseWriter
+
+			for i, command := range tc.commands {
+				rw = &ResponseWriter{}
+				cm.Handle(ctx, rw, strings.NewReader(command))
+
+				doc := etree.NewDocument()
+				require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+				assert.Equal(t, tc.expectCodes[i], doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+				assert.Equal(t, "ABC-12345", doc.FindElement("/epp/response/trID/clTRID").Text())
+			}
+
+			assert.Equal(t, tc.expectClose, rw.ShouldCloseAfterWrite())
+			assert.Equal(t, tc.expectLoggedIn, session.LoggedIn())
+
+			if tc.expectLoggedIn {
+				assert.Equal(t, "ClientX", session.ClientID())
+				assert.Equal(t, Namespaces{NamespaceIETFDomain10}, session.ObjectNamespaces())
+				assert.Equal(t, Namespaces{NamespaceIETFSecDNS11}, session.ExtensionNamespaces())
+			}
+
+			if changer, ok := tc.authenticator.(*testPasswordChanger); ok {
+				assert.Equal(t, tc.expectNewPassword, changer.newPassword)
+			}
+		})
+	}
+}
+
+func TestMux_EnforceServices(t *testing.T) {
+	t.Parallel()
+
+	var handled bool
+
+	cm := &CommandMux{EnforceServices: true}
+	cm.BindCommand("info", NamespaceIETFDomain10.String(), func(context.Context, Writer, *etree.Document) {
+		handled = true
+	})
+	cm.BindCommand("info", NamespaceIETFHost10.String(), func(context.Context, Writer, *etree.Document) {
+		handled = true
+	})
+
+	const (
+		domainInfo = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+</info>%s</command></epp>`
+		hostInfo = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<host:info xmlns:host="urn:ietf:params:xml:ns:host-1.0"><host:name>ns1.example.se</host:name></host:info>
+</info></command></epp>`
+		twoObjectsInfo = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+<host:info xmlns:host="urn:ietf:params:xml:ns:host-1.0"><host:name>ns1.example.se</host:name></host:info>
+</info></command></epp>`
+		foreignRootInfo = `<x><command xmlns="urn:ietf:params:xml:ns:epp-1.0"><info>
+<host:info xmlns:host="urn:ietf:params:xml:ns:host-1.0"><host:name>ns1.example.se</host:name></host:info>
+</info></command></x>`
+		secDNSExtension  = `<extension><secDNS:info xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1"/></extension>`
+		iisExtension     = `<extension><iis:info xmlns:iis="urn:se:iis:xml:epp:iis-1.2"/></extension>`
+		unknownExtension = `<extension><foo:info xmlns:foo="urn:example:foo-1.0"/></extension>`
+	)
+
+	for _, tc := range []struct {
+		name          string
+		loggedIn      bool
+		command       string
+		expectCode    string
+		expectHandled bool
+	}{
+		{
+			name:          "not logged in",
+			command:       hostInfo,
+			expectHandled: true,
+		},
+		{
+			name:          "negotiated object",
+			loggedIn:      true,
+			command:       fmt.Sprintf(domainInfo, ""),
+			expectHandled: true,
+		},
+		{
+			name:       "object not negotiated",
+			loggedIn:   true,
+			command:    hostInfo,
+			expectCode: "2307",
+		},
+		{
+			name:       "second object not negotiated",
+			loggedIn:   true,
+			command:    twoObjectsInfo,
+			expectCode: "2307",
+		},
+		{
+			name:       "command in foreign root",
+			loggedIn:   true,
+			command:    foreignRootInfo,
+			expectCode: "2001",
+		},
+		{
+			name:          "negotiated extension",
+			loggedIn:      true,
+			command:       fmt.Sprintf(domainInfo, secDNSExtension),
+			expectHandled: true,
+		},
+		{
+			name:       "extension not negotiated",
+			loggedIn:   true,
+			command:    fmt.Sprintf(domainInfo, iisExtension),
+			expectCode: "2103",
+		},
+		{
+			name:       "unknown extension",
+			loggedIn:   true,
+			command:    fmt.Sprintf(domainInfo, unknownExtension),
+			expectCode: "2103",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handled = false
+
+			session := &Session{}
+
+			if tc.loggedIn {
+				require.NoError(t, session.Login("ClientX", "en",
+					Namespaces{NamespaceIETFDomain10, NamespaceUnknown},
+					Namespaces{NamespaceIETFSecDNS11},
+				))
+			}
+
+			rw := &ResponseWriter{}
+			cm.Handle(ContextWithSession(context.Background(), session), rw, strings.NewReader(tc.command))
+
+			assert.Equal(t, tc.expectHandled, handled)
+
+			if tc.expectCode == "" {
+				assert.Zero(t, rw.Len())
+				return
+			}
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+			assert.Equal(t, tc.expectCode, doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+			assert.False(t, rw.ShouldCloseAfterWrite())
+		})
+	}
+}
+
+func TestMux_Use(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	middleware := func(name string) Middleware {
+		return func(next CommandFunc) CommandFunc {
+			return func(ctx context.Context, rw Writer, doc *etree.Document) {
+				calls = append(calls, name+" before")
+				next(ctx, rw, doc)
+				calls = append(calls, name+" after")
+			}
+		}
+	}
+
+	cm := &CommandMux{}
+	cm.Use(middleware("first"))
+	cm.BindCommand("info", NamespaceIETFDomain10.String(),
+		func(context.Context, Writer, *etree.Document) {
+			calls = append(calls, "domain")
+		},
+		middleware("route 1"), middleware("route 2"),
+	)
+	cm.BindCommand("info", NamespaceIETFHost10.String(),
+		func(context.Context, Writer, *etree.Document) {
+			calls = append(calls, "host")
+		},
+	)
+	cm.Use(middleware("second"))
+
+	cm.Handle(context.Background(), &ResponseWriter{}, strings.NewReader(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+</info></command></epp>`))
+
+	assert.Equal(t, []string{
+		"first before",
+		"second before",
+		"route 1 before",
+		"route 2 before",
+		"domain",
+		"route 2 after",
+		"route 1 after",
+		"second after",
+		"first after",
+	}, calls)
+
+	calls = nil
+
+	cm.Handle(context.Background(), &ResponseWriter{}, strings.NewReader(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<host:info xmlns:host="urn:ietf:params:xml:ns:host-1.0"><host:name>ns1.example.se</host:name></host:info>
+</info></command></epp>`))
+
+	assert.Equal(t, []string{
+		"first before",
+		"second before",
+		"host",
+		"second after",
+		"first after",
+	}, calls)
+}
+
 func TestMux_Bind(t *testing.T) {
 	t.Parallel()
 
@@ -156,6 +755,111 @@ func TestMux_BindCommand(t *testing.T) {
 	}
 }
 
+func TestMux_BindCommandExtension(t *testing.T) {
+	t.Parallel()
+
+	var (
+		extensionCalled bool
+		commandCalled   bool
+	)
+
+	cm := &CommandMux{}
+	cm.BindCommandExtension("update", NamespaceIETFDomain10.String(), "update", NamespaceIISEpp12.String(),
+		func(context.Context, Writer, *etree.Document) {
+			extensionCalled = true
+		},
+	)
+	cm.BindCommand("update", NamespaceIETFDomain10.String(),
+		func(context.Context, Writer, *etree.Document) {
+			commandCalled = true
+		},
+	)
+
+	for _, tc := range []struct {
+		name                  string
+		command               string
+		expectExtensionCalled bool
+		expectCommandCalled   bool
+	}{
+		{
+			name:                  "command with extension",
+			expectExtensionCalled: true,
+			command: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><update>
+<domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:update>
+</update><extension><iis:update xmlns:iis="urn:se:iis:xml:epp:iis-1.2"><iis:clientDelete>1</iis:clientDelete></iis:update></extension>
+</command></epp>`,
+		},
+		{
+			name:                "command without extension",
+			expectCommandCalled: true,
+			command: `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><update>
+<domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:update>
+</update></command></epp>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			extensionCalled = false
+			commandCalled = false
+
+			cm.Handle(context.Background(), &ResponseWriter{}, strings.NewReader(tc.command))
+
+			assert.Equal(t, tc.expectExtensionCalled, extensionCalled)
+			assert.Equal(t, tc.expectCommandCalled, commandCalled)
+		})
+	}
+}
+
+func TestMux_BindGreetingBuilder(t *testing.T) {
+	t.Parallel()
+
+	handler := func(context.Context, Writer, *etree.Document) {}
+
+	for _, tc := range []struct {
+		name                 string
+		autoGreetingServices bool
+		expectObjURIs        []string
+		expectExtURIs        []string
+	}{
+		{
+			name:          "only configured services",
+			expectObjURIs: []string{NamespaceIETFHost10.String()},
+			expectExtURIs: []string{},
+		},
+		{
+			name:                 "services from bound handlers",
+			autoGreetingServices: true,
+			expectObjURIs: []string{
+				NamespaceIETFHost10.String(),
+				NamespaceIETFDomain10.String(),
+				NamespaceIETFContact10.String(),
+			},
+			expectExtURIs: []string{NamespaceIISEpp12.String()},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cm := &CommandMux{AutoGreetingServices: tc.autoGreetingServices}
+			cm.BindGreetingBuilder(&GreetingBuilder{
+				ServerID: "epp",
+				ObjURIs:  []string{NamespaceIETFHost10.String()},
+			})
+			cm.Bind(NewXMLPathBuilder().AddOrphan("//hello", NamespaceIETFEPP10.String()).String(), handler)
+			cm.BindCommandExtension("create", NamespaceIETFDomain10.String(), "create", NamespaceIISEpp12.String(), handler)
+			cm.BindCommand("create", NamespaceIETFDomain10.String(), handler)
+			cm.BindCommand("info", NamespaceIETFContact10.String(), handler)
+			cm.BindCommand("info", NamespaceIETFHost10.String(), handler)
+
+			rw := &ResponseWriter{}
+			cm.GetGreeting(context.Background(), rw)
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+
+			assert.Equal(t, tc.expectObjURIs, texts(doc.FindElements("/epp/greeting/svcMenu/objURI")))
+			assert.Equal(t, tc.expectExtURIs, texts(doc.FindElements("/epp/greeting/svcMenu/svcExtension/extURI")))
+		})
+	}
+}
+
 func writeAndClose(w io.WriteCloser, data string) {
 	_, err := w.Write([]byte(data))
 	if err != nil {
@@ -1,5 +1,13 @@
 package epplib
 
+import (
+	"cmp"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
 type (
 	// Namespace is the enum type for namespaces.
 	Namespace int
@@ -19,6 +27,7 @@ const (
 	NamespaceIETFSecDNS11
 	NamespaceIISEpp12
 	NamespaceIISRegistryLock10
+	NamespaceIETFRGP10
 )
 
 var (
@@ -32,6 +41,7 @@ var (
 		"urn:ietf:params:xml:ns:secDNS-1.1":         NamespaceIETFSecDNS11,
 		"urn:se:iis:xml:epp:iis-1.2":                NamespaceIISEpp12,
 		"urn:se:iis:xml:epp:registryLock-1.0":       NamespaceIISRegistryLock10,
+		"urn:ietf:params:xml:ns:rgp-1.0":            NamespaceIETFRGP10,
 	}
 	namespaceToStringMap = map[Namespace]string{
 		NamespaceIETFEPP10:         "urn:ietf:params:xml:ns:epp-1.0",
@@ -43,6 +53,7 @@ var (
 		NamespaceIETFSecDNS11:      "urn:ietf:params:xml:ns:secDNS-1.1",
 		NamespaceIISEpp12:          "urn:se:iis:xml:epp:iis-1.2",
 		NamespaceIISRegistryLock10: "urn:se:iis:xml:epp:registryLock-1.0",
+		NamespaceIETFRGP10:         "urn:ietf:params:xml:ns:rgp-1.0",
 	}
 	objectNamespaceMap = map[Namespace]struct{}{
 		NamespaceIETFHost10:    {},
@@ -54,35 +65,46 @@ var (
 		NamespaceIETFSecDNS11:      {},
 		NamespaceIISEpp12:          {},
 		NamespaceIISRegistryLock10: {},
+		NamespaceIETFRGP10:         {},
 	}
 )
 
 // NamespaceFromString return a namespace from a given string.
-// If the namespace is not supported NamespaceUnknown will be returned.
+// If the namespace is neither declared as a constant nor registered with
+// RegisterNamespace NamespaceUnknown will be returned.
 func NamespaceFromString(ns string) Namespace {
-	ret, ok := stringToNamespaceMap[ns]
-	if !ok {
-		return NamespaceUnknown
-	}
+	return defaultNamespaceRegistry.lookup(ns)
+}
 
-	return ret
+// RegisterNamespace registers a namespace at runtime and returns its value. It
+// is given a value after the constants. Registering a URI that is already
+// registered with the same type returns the existing value, registering it
+// with a different type returns ErrNamespaceRegistered.
+func RegisterNamespace(info NamespaceInfo) (Namespace, error) {
+	return defaultNamespaceRegistry.register(info)
 }
 
 // String return the namespace as a string.
 func (n Namespace) String() string {
-	return namespaceToStringMap[n]
+	info, _ := defaultNamespaceRegistry.info(n)
+	return info.URI
+}
+
+// Info returns the information about a declared or registered namespace.
+func (n Namespace) Info() (NamespaceInfo, bool) {
+	return defaultNamespaceRegistry.info(n)
 }
 
 // IsObjectNamespace can be used to see if a given namespace is of the object type.
 func (n Namespace) IsObjectNamespace() bool {
-	_, ok := objectNamespaceMap[n]
-	return ok
+	info, _ := defaultNamespaceRegistry.info(n)
+	return info.Type == NamespaceTypeObject
 }
 
 // IsExtensionNamespace can be used to see if a given namespace is of the extension type.
 func (n Namespace) IsExtensionNamespace() bool {
-	_, ok := extensionNamespaceMap[n]
-	return ok
+	info, _ := defaultNamespaceRegistry.info(n)
+	return info.Type == NamespaceTypeExtension
 }
 
 // HasNamespace check if a given namespace is in the list of namespaces.
@@ -95,3 +117,243 @@ func (ns Namespaces) HasNamespace(wantedNs Namespace) bool {
 
 	return false
 }
+
+// NamespaceType is the classification of a namespace.
+type NamespaceType int
+
+// Namespace types.
+const (
+	// NamespaceTypeOther is used for namespaces that are neither objects nor
+	// extensions, e.g. the EPP namespace itself.
+	NamespaceTypeOther NamespaceType = iota
+	NamespaceTypeObject
+	NamespaceTypeExtension
+)
+
+// Errors returned when registering namespaces.
+var (
+	ErrInvalidNamespace    = errors.New("invalid namespace")
+	ErrNamespaceRegistered = errors.New("namespace already registered")
+)
+
+// NamespaceInfo describes a registered namespace. Namespaces with the same
+// Family are different versions of the same schema. Empty fields are derived
+// from the URI, e.g. urn:ietf:params:xml:ns:domain-1.0 has the Family
+// "urn:ietf:params:xml:ns:domain", the Version "1.0" and the Prefix "domain".
+type NamespaceInfo struct {
+	URI     string
+	Type    NamespaceType
+	Family  string
+	Version string
+	Prefix  string
+}
+
+// defaultNamespaceRegistry is the registry of all namespaces. There is only
+// one registry so that every Namespace value resolves to a single URI.
+var defaultNamespaceRegistry = newNamespaceRegistry()
+
+// namespaceRegistry keeps track of known namespaces. It is safe for concurrent
+// use. Namespaces registered at runtime are given values after the
+// constants.
+type namespaceRegistry struct {
+	mu         sync.RWMutex
+	namespaces map[string]Namespace
+	infos      map[Namespace]NamespaceInfo
+	next       Namespace
+}
+
+// newNamespaceRegistry returns a registry with the namespaces declared as
+// constants in this package.
+func newNamespaceRegistry() *namespaceRegistry {
+	r := &namespaceRegistry{
+		namespaces: make(map[string]Namespace, len(stringToNamespaceMap)),
+		infos:      make(map[Namespace]NamespaceInfo, len(namespaceToStringMap)),
+	}
+
+	for ns, uri := range namespaceToStringMap {
+		info := NamespaceInfo{URI: uri, Type: NamespaceTypeOther}
+
+		if _, ok := objectNamespaceMap[ns]; ok {
+			info.Type = NamespaceTypeObject
+		}
+
+		if _, ok := extensionNamespaceMap[ns]; ok {
+			info.Type = NamespaceTypeExtension
+		}
+
+		r.add(ns, info)
+	}
+
+	return r
+}
+
+// register registers a namespace, see RegisterNamespace.
+func (r *namespaceRegistry) register(info NamespaceInfo) (Namespace, error) {
+	if info.URI == "" {
+		return NamespaceUnknown, ErrInvalidNamespace
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ns, ok := r.namespaces[info.URI]; ok {
+		if r.infos[ns].Type != info.Type {
+			return NamespaceUnknown, ErrNamespaceRegistered
+		}
+
+		return ns, nil
+	}
+
+	ns := r.next + 1
+	r.add(ns, info)
+
+	return ns, nil
+}
+
+// add adds the namespace without locking, filling in the family, version and
+// prefix if missing.
+func (r *namespaceRegistry) add(ns Namespace, info NamespaceInfo) {
+	family, version := splitNamespaceVersion(info.URI)
+
+	if info.Family == "" {
+		info.Family = family
+	}
+
+	if info.Version == "" {
+		info.Version = version
+	}
+
+	if info.Prefix == "" {
+		info.Prefix = defaultNamespacePrefix(info.URI)
+	}
+
+	r.namespaces[info.URI] = ns
+	r.infos[ns] = info
+
+	if ns > r.next {
+		r.next = ns
+	}
+}
+
+// lookup returns the namespace for the URI or NamespaceUnknown if it isn't
+// registered.
+func (r *namespaceRegistry) lookup(uri string) Namespace {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.namespaces[uri]
+}
+
+// info returns the information about a registered namespace.
+func (r *namespaceRegistry) info(ns Namespace) (NamespaceInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info, ok := r.infos[ns]
+
+	return info, ok
+}
+
+// infoByURI returns the information about a registered namespace URI.
+func (r *namespaceRegistry) infoByURI(uri string) (NamespaceInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ns, ok := r.namespaces[uri]
+	if !ok {
+		return NamespaceInfo{}, false
+	}
+
+	return r.infos[ns], true
+}
+
+// negotiate negotiates the namespaces, see NegotiateNamespaces.
+func (r *namespaceRegistry) negotiate(offered []string, supported Namespaces) Namespaces {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chosen := map[string]Namespace{}
+
+	for _, uri := range offered {
+		ns, ok := r.namespaces[uri]
+		if !ok || !supported.HasNamespace(ns) {
+			continue
+		}
+
+		info := r.infos[ns]
+
+		if current, ok := chosen[info.Family]; ok &&
+			compareNamespaceVersions(r.infos[current].Version, info.Version) >= 0 {
+			continue
+		}
+
+		chosen[info.Family] = ns
+	}
+
+	var negotiated Namespaces
+
+	for _, ns := range supported {
+		if chosen[r.infos[ns].Family] == ns && !negotiated.HasNamespace(ns) {
+			negotiated = append(negotiated, ns)
+		}
+	}
+
+	return negotiated
+}
+
+// NegotiateNamespaces returns the namespaces to use for a session where the
+// client offered the namespace URIs, e.g. from the login svcs, and the server
+// supports the namespaces. For every family the highest version both sides
+// support is chosen. Unknown URIs and families the server doesn't support are
+// ignored. The namespaces are returned in the order of supported.
+func NegotiateNamespaces(offered []string, supported Namespaces) Namespaces {
+	return defaultNamespaceRegistry.negotiate(offered, supported)
+}
+
+// compareNamespaceVersions compares two dotted versions numerically, e.g.
+// "1.10" is greater than "1.2" and "1" equals "1.0". Parts that aren't numbers
+// are compared as strings.
+func compareNamespaceVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := "0", "0"
+
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNum, aErr := strconv.Atoi(aPart)
+		bNum, bErr := strconv.Atoi(bPart)
+
+		switch {
+		case aErr == nil && bErr == nil && aNum != bNum:
+			return cmp.Compare(aNum, bNum)
+		case (aErr != nil || bErr != nil) && aPart != bPart:
+			return strings.Compare(aPart, bPart)
+		}
+	}
+
+	return 0
+}
+
+// splitNamespaceVersion splits a URI into the part before the version and the
+// version, e.g. "urn:ietf:params:xml:ns:domain" and "1.0". If the URI has no
+// version the version is empty.
+func splitNamespaceVersion(uri string) (string, string) {
+	i := strings.LastIndexByte(uri, '-')
+	if i <= 0 || strings.ContainsAny(uri[i:], ":/") {
+		return uri, ""
+	}
+
+	if _, err := strconv.ParseFloat(uri[i+1:], 64); err != nil {
+		return uri, ""
+	}
+
+	return uri[:i], uri[i+1:]
+}
@@ -1,9 +1,12 @@
 package epplib
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNamespace(t *testing.T) {
@@ -46,6 +49,7 @@ func TestNamespace_Types(t *testing.T) {
 				NamespaceIETFSecDNS11,
 				NamespaceIISEpp12,
 				NamespaceIISRegistryLock10,
+				NamespaceIETFRGP10,
 			},
 			isExtensionNs: true,
 		},
@@ -72,3 +76,188 @@ func TestNamespaces(t *testing.T) {
 	assert.True(t, ns.HasNamespace(NamespaceIETFDomain10))
 	assert.False(t, ns.HasNamespace(NamespaceIETFContact10))
 }
+
+func TestNamespaceRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := newNamespaceRegistry()
+
+	assert.Equal(t, NamespaceIETFDomain10, r.lookup("urn:ietf:params:xml:ns:domain-1.0"))
+
+	info, ok := r.info(NamespaceIETFSecDNS11)
+	require.True(t, ok)
+	assert.Equal(t, NamespaceInfo{
+		URI:     "urn:ietf:params:xml:ns:secDNS-1.1",
+		Type:    NamespaceTypeExtension,
+		Family:  "urn:ietf:params:xml:ns:secDNS",
+		Version: "1.1",
+		Prefix:  "secDNS",
+	}, info)
+
+	fee, err := r.register(NamespaceInfo{URI: "urn:ietf:params:xml:ns:epp:fee-1.0", Type: NamespaceTypeExtension})
+	require.NoError(t, err)
+	assert.Greater(t, fee, NamespaceIETFRGP10)
+	assert.Equal(t, fee, r.lookup("urn:ietf:params:xml:ns:epp:fee-1.0"))
+
+	info, ok = r.info(fee)
+	require.True(t, ok)
+	assert.Equal(t, "1.0", info.Version)
+	assert.Equal(t, "fee", info.Prefix)
+
+	again, err := r.register(NamespaceInfo{URI: "urn:ietf:params:xml:ns:epp:fee-1.0", Type: NamespaceTypeExtension})
+	require.NoError(t, err)
+	assert.Equal(t, fee, again)
+
+	_, err = r.register(NamespaceInfo{URI: "urn:ietf:params:xml:ns:epp:fee-1.0", Type: NamespaceTypeObject})
+	require.ErrorIs(t, err, ErrNamespaceRegistered)
+
+	_, err = r.register(NamespaceInfo{})
+	require.ErrorIs(t, err, ErrInvalidNamespace)
+
+	launch, err := r.register(NamespaceInfo{URI: "urn:ietf:params:xml:ns:launch-1.0", Type: NamespaceTypeExtension, Prefix: "l"})
+	require.NoError(t, err)
+	assert.NotEqual(t, fee, launch)
+
+	info, _ = r.infoByURI("urn:ietf:params:xml:ns:launch-1.0")
+	assert.Equal(t, "l", info.Prefix)
+
+	// The registry is separate from the default one.
+	assert.Equal(t, NamespaceUnknown, NamespaceFromString("urn:ietf:params:xml:ns:launch-1.0"))
+}
+
+func TestRegisterNamespace(t *testing.T) {
+	t.Parallel()
+
+	ns, err := RegisterNamespace(NamespaceInfo{
+		URI:    "urn:example:epp:custom-2.1",
+		Type:   NamespaceTypeObject,
+		Prefix: "cust",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, ns, NamespaceFromString("urn:example:epp:custom-2.1"))
+	assert.Equal(t, "urn:example:epp:custom-2.1", ns.String())
+	assert.True(t, ns.IsObjectNamespace())
+	assert.False(t, ns.IsExtensionNamespace())
+	assert.Equal(t, "cust", namespacePrefix("urn:example:epp:custom-2.1"))
+
+	info, ok := ns.Info()
+	require.True(t, ok)
+	assert.Equal(t, "2.1", info.Version)
+}
+
+func TestNamespaceRegistry_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		r  = newNamespaceRegistry()
+		wg sync.WaitGroup
+	)
+
+	for i := range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			uri := fmt.Sprintf("urn:example:ext%d-1.0", i%3)
+
+			ns, err := r.register(NamespaceInfo{URI: uri, Type: NamespaceTypeExtension})
+			assert.NoError(t, err)
+			assert.Equal(t, ns, r.lookup(uri))
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range 3 {
+		assert.NotEqual(t, NamespaceUnknown, r.lookup(fmt.Sprintf("urn:example:ext%d-1.0", i)))
+	}
+}
+
+func TestSplitNamespaceVersion(t *testing.T) {
+	t.Parallel()
+
+	for uri, expected := range map[string][2]string{
+		"urn:ietf:params:xml:ns:domain-1.0":         {"urn:ietf:params:xml:ns:domain", "1.0"},
+		"urn:se:iis:xml:epp:registryLock-1.0":       {"urn:se:iis:xml:epp:registryLock", "1.0"},
+		"http://www.w3.org/2001/XMLSchema-instance": {"http://www.w3.org/2001/XMLSchema-instance", ""},
+		"urn:example:no-version:ext":                {"urn:example:no-version:ext", ""},
+	} {
+		family, version := splitNamespaceVersion(uri)
+		assert.Equal(t, expected, [2]string{family, version}, uri)
+	}
+}
+
+func TestNegotiateNamespaces(t *testing.T) {
+	t.Parallel()
+
+	r := newNamespaceRegistry()
+
+	fee05, err := r.register(NamespaceInfo{URI: "urn:ietf:params:xml:ns:fee-0.5", Type: NamespaceTypeExtension})
+	require.NoError(t, err)
+
+	fee10, err := r.register(NamespaceInfo{URI: "urn:ietf:params:xml:ns:epp:fee-1.0", Type: NamespaceTypeExtension, Family: "urn:ietf:params:xml:ns:fee"})
+	require.NoError(t, err)
+
+	supported := Namespaces{
+		NamespaceIETFDomain10,
+		NamespaceIETFSecDNS10,
+		NamespaceIETFSecDNS11,
+		fee05,
+		fee10,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		offered  []string
+		expected Namespaces
+	}{
+		{
+			name:     "both secDNS versions",
+			offered:  []string{"urn:ietf:params:xml:ns:secDNS-1.1", "urn:ietf:params:xml:ns:secDNS-1.0"},
+			expected: Namespaces{NamespaceIETFSecDNS11},
+		},
+		{
+			name:     "only secDNS-1.0",
+			offered:  []string{"urn:ietf:params:xml:ns:domain-1.0", "urn:ietf:params:xml:ns:secDNS-1.0"},
+			expected: Namespaces{NamespaceIETFDomain10, NamespaceIETFSecDNS10},
+		},
+		{
+			name:     "family with different uris",
+			offered:  []string{"urn:ietf:params:xml:ns:fee-0.5", "urn:ietf:params:xml:ns:epp:fee-1.0"},
+			expected: Namespaces{fee10},
+		},
+		{
+			name:     "unknown and unsupported namespaces",
+			offered:  []string{"urn:example:unknown-1.0", "urn:ietf:params:xml:ns:host-1.0", "urn:ietf:params:xml:ns:fee-0.5"},
+			expected: Namespaces{fee05},
+		},
+		{
+			name: "nothing offered",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, r.negotiate(tc.offered, supported))
+		})
+	}
+}
+
+func TestCompareNamespaceVersions(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.0", b: "1.1", expected: -1},
+		{a: "1.10", b: "1.2", expected: 1},
+		{a: "1", b: "1.0", expected: 0},
+		{a: "2.0", b: "1.9", expected: 1},
+		{a: "1.0a", b: "1.0b", expected: -1},
+	} {
+		assert.Equal(t, tc.expected, compareNamespaceVersions(tc.a, tc.b), tc.a+" "+tc.b)
+	}
+}
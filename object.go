@@ -0,0 +1,286 @@
+package epplib
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// commandObjectElement returns the object element of a command, e.g.
+// <domain:check> for a domain check command, or a 2001 error if the document
+// isn't such a command.
+func commandObjectElement(doc *etree.Document, command, ns string) (*etree.Element, error) {
+	el := doc.FindElement(NewXMLPathBuilder().
+		Add("epp", NamespaceIETFEPP10.String()).
+		Add("command", NamespaceIETFEPP10.String()).
+		Add(command, NamespaceIETFEPP10.String()).
+		Add(command, ns).String())
+	if el == nil {
+		return nil, NewError(StatusCommandSyntaxError)
+	}
+
+	return el, nil
+}
+
+// commandExtensionElement returns the element with the tag in the namespace
+// from the extension of a command or nil if there is none.
+func commandExtensionElement(doc *etree.Document, tag, ns string) *etree.Element {
+	return doc.FindElement(NewXMLPathBuilder().
+		Add("epp", NamespaceIETFEPP10.String()).
+		Add("command", NamespaceIETFEPP10.String()).
+		Add("extension", NamespaceIETFEPP10.String()).
+		Add(tag, ns).String())
+}
+
+// ObjectStatus represent the status element of a domain, contact or host.
+type ObjectStatus struct {
+	Status string
+	Lang   string
+	Reason string
+}
+
+// parseObjectStatuses parses the status children of el in the namespace. A
+// status that isn't one of the allowed values is a 2005 error.
+func parseObjectStatuses(el *etree.Element, ns string, allowed []string) ([]ObjectStatus, error) {
+	var statuses []ObjectStatus
+
+	for _, status := range childElements(el, "status", ns) {
+		s := ObjectStatus{
+			Status: status.SelectAttrValue("s", ""),
+			Lang:   status.SelectAttrValue("lang", ""),
+			Reason: strings.TrimSpace(status.Text()),
+		}
+
+		if !slices.Contains(allowed, s.Status) {
+			return nil, syntaxError("status", ns, s.Status)
+		}
+
+		statuses = append(statuses, s)
+	}
+
+	return statuses, nil
+}
+
+// parseTransferOp returns the op attribute of the transfer command that is the
+// parent of the object element el.
+func parseTransferOp(el *etree.Element) (string, error) {
+	op := el.Parent().SelectAttrValue("op", "")
+	if !slices.Contains([]string{"request", "query", "approve", "reject", "cancel"}, op) {
+		return "", syntaxError("transfer", NamespaceIETFEPP10.String(), op)
+	}
+
+	return op, nil
+}
+
+// childElements returns the children of el with the tag in the namespace.
+func childElements(el *etree.Element, tag, ns string) []*etree.Element {
+	var children []*etree.Element
+
+	for _, child := range el.ChildElements() {
+		if child.Tag == tag && child.NamespaceURI() == ns {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+// childElement returns the first child of el with the tag in the namespace or
+// nil if there is none.
+func childElement(el *etree.Element, tag, ns string) *etree.Element {
+	for _, child := range el.ChildElements() {
+		if child.Tag == tag && child.NamespaceURI() == ns {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// childText returns the trimmed text of the first child of el with the tag in
+// the namespace.
+func childText(el *etree.Element, tag, ns string) string {
+	child := childElement(el, tag, ns)
+	if child == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(child.Text())
+}
+
+// childTexts returns the trimmed texts of the children of el with the tag in
+// the namespace.
+func childTexts(el *etree.Element, tag, ns string) []string {
+	var texts []string
+
+	for _, child := range childElements(el, tag, ns) {
+		texts = append(texts, strings.TrimSpace(child.Text()))
+	}
+
+	return texts
+}
+
+// requiredChildText returns the text of the first child of el with the tag in
+// the namespace or a 2003 error if it is missing or empty.
+func requiredChildText(el *etree.Element, tag, ns string) (string, error) {
+	text := childText(el, tag, ns)
+	if text == "" {
+		return "", missingParameterError(tag, ns)
+	}
+
+	return text, nil
+}
+
+// createChild creates a child element with the same prefix as el.
+func createChild(el *etree.Element, tag string) *etree.Element {
+	return el.CreateElement(el.Space + ":" + tag)
+}
+
+// createTextChild creates a child element with the same prefix as el and the
+// text.
+func createTextChild(el *etree.Element, tag, text string) *etree.Element {
+	child := createChild(el, tag)
+	child.SetText(text)
+
+	return child
+}
+
+// createOptionalTextChild is like createTextChild but does nothing for an
+// empty text.
+func createOptionalTextChild(el *etree.Element, tag, text string) {
+	if text != "" {
+		createTextChild(el, tag, text)
+	}
+}
+
+// createOptionalDateTimeChild creates a child element with the time unless it
+// is zero.
+func createOptionalDateTimeChild(el *etree.Element, tag string, t time.Time) {
+	if !t.IsZero() {
+		createTextChild(el, tag, formatDateTime(t))
+	}
+}
+
+// createStatusChildren creates a status element for every status.
+func createStatusChildren(el *etree.Element, statuses []ObjectStatus) {
+	for _, status := range statuses {
+		s := createTextChild(el, "status", status.Reason)
+		s.CreateAttr("s", status.Status)
+
+		if status.Lang != "" {
+			s.CreateAttr("lang", status.Lang)
+		}
+	}
+}
+
+// createCheckDataChild creates a cd element of a check response with the
+// object identifier element with the tag, e.g. name or id, and the reason
+// unless it is empty.
+func createCheckDataChild(el *etree.Element, tag, value string, available bool, reason, reasonLang string) {
+	cd := createChild(el, "cd")
+
+	id := createTextChild(cd, tag, value)
+	id.CreateAttr("avail", FormatXMLBool(available))
+
+	if reason != "" {
+		r := createTextChild(cd, "reason", reason)
+
+		if reasonLang != "" {
+			r.CreateAttr("lang", reasonLang)
+		}
+	}
+}
+
+// newPrefixedElement creates an element with a prefix derived from the
+// namespace and the namespace declared on it.
+func newPrefixedElement(namespace, tag string) *etree.Element {
+	prefix := namespacePrefix(namespace)
+
+	el := etree.NewElement(prefix + ":" + tag)
+	el.CreateAttr("xmlns:"+prefix, namespace)
+
+	return el
+}
+
+// parseDateTime parses an XML Schema dateTime.
+func parseDateTime(el *etree.Element, tag, ns string) (time.Time, error) {
+	text := childText(el, tag, ns)
+	if text == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return time.Time{}, syntaxError(tag, ns, text)
+	}
+
+	return t, nil
+}
+
+// missingParameterError returns a 2003 error for the missing element.
+func missingParameterError(tag, ns string) *EppError {
+	return NewError(StatusMissingParameter).WithValues(Value{
+		Element:   tag,
+		Namespace: ns,
+	})
+}
+
+// syntaxError returns a 2005 error for the element with an invalid value.
+func syntaxError(tag, ns, value string) *EppError {
+	return NewError(StatusValueSyntaxError).WithValues(Value{
+		Element:   tag,
+		Value:     value,
+		Namespace: ns,
+	})
+}
+
+// rangeError returns a 2004 error for the element with a value out of range.
+func rangeError(tag, ns, value string) *EppError {
+	return NewError(StatusValueRangeError).WithValues(Value{
+		Element:   tag,
+		Value:     value,
+		Namespace: ns,
+	})
+}
+
+// parseIPAddress parses an address element with an ip attribute, "v4" or
+// "v6" defaulting to "v4", and verifies that the address matches the
+// attribute.
+func parseIPAddress(el *etree.Element, ns string) (netip.Addr, error) {
+	text := strings.TrimSpace(el.Text())
+
+	addr, err := netip.ParseAddr(text)
+	if err != nil || addr.Zone() != "" {
+		return netip.Addr{}, syntaxError(el.Tag, ns, text)
+	}
+
+	switch el.SelectAttrValue("ip", "v4") {
+	case "v4":
+		if !addr.Is4() {
+			return netip.Addr{}, syntaxError(el.Tag, ns, text)
+		}
+	case "v6":
+		if !addr.Is6() || addr.Is4In6() {
+			return netip.Addr{}, syntaxError(el.Tag, ns, text)
+		}
+	default:
+		return netip.Addr{}, syntaxError(el.Tag, ns, text)
+	}
+
+	return addr, nil
+}
+
+// createIPAddressChild creates an address element with the ip attribute set
+// from the address family.
+func createIPAddressChild(el *etree.Element, tag string, addr netip.Addr) {
+	child := createTextChild(el, tag, addr.String())
+
+	if addr.Is4() {
+		child.CreateAttr("ip", "v4")
+	} else {
+		child.CreateAttr("ip", "v6")
+	}
+}
@@ -0,0 +1,227 @@
+package epplib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidProxyHeader is returned when a connection from a trusted proxy
+// doesn't start with a valid PROXY protocol header.
+var ErrInvalidProxyHeader = errors.New("invalid proxy protocol header")
+
+// proxyV2Signature starts every PROXY protocol version 2 header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxyV1MaxLength is the maximum length of a version 1 header including the
+// CRLF.
+const proxyV1MaxLength = 107
+
+// ProxyProtocol configures reading PROXY protocol version 1 and 2 headers,
+// https://www.haproxy.org/download/2.9/doc/proxy-protocol.txt, sent by a load
+// balancer in front of the server.
+type ProxyProtocol struct {
+	// TrustedProxies are the networks of the load balancers. Connections
+	// from them must start with a PROXY protocol header and get the client
+	// address from it. Connections from other addresses are served without
+	// reading a header.
+	TrustedProxies []netip.Prefix
+
+	// HeaderTimeout is how long to wait for the header. 0 uses the
+	// ReadTimeout of the Server, or 10 seconds if that isn't set either.
+	HeaderTimeout time.Duration
+}
+
+// defaultProxyHeaderTimeout is how long to wait for the header when neither
+// HeaderTimeout nor ReadTimeout is set.
+const defaultProxyHeaderTimeout = 10 * time.Second
+
+// headerTimeout returns how long to wait for the header.
+func (p *ProxyProtocol) headerTimeout(readTimeout time.Duration) time.Duration {
+	switch {
+	case p.HeaderTimeout > 0:
+		return p.HeaderTimeout
+	case readTimeout > 0:
+		return readTimeout
+	default:
+		return defaultProxyHeaderTimeout
+	}
+}
+
+// trusted reports if the connection is from a trusted proxy.
+func (p *ProxyProtocol) trusted(addr net.Addr) bool {
+	ip, err := remoteIP(addr)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(p.TrustedProxies, func(prefix netip.Prefix) bool {
+		return prefix.Contains(ip)
+	})
+}
+
+// readHeader reads the header from the connection and returns a connection
+// with the remote address from the header. Health checks from the proxy, with
+// the LOCAL command or the UNKNOWN protocol, keep the address of the proxy.
+// The read deadline of the connection is left to the caller.
+func (p *ProxyProtocol) readHeader(conn net.Conn) (net.Conn, error) {
+	r := bufio.NewReaderSize(conn, 256)
+
+	remoteAddr, err := readProxyHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if remoteAddr == nil {
+		remoteAddr = conn.RemoteAddr()
+	}
+
+	return &proxiedConn{Conn: conn, r: r, remoteAddr: remoteAddr}, nil
+}
+
+// proxiedConn is a connection with the remote address from a PROXY protocol
+// header. Reads go through the reader used to read the header since it may
+// have buffered the start of the TLS handshake.
+type proxiedConn struct {
+	net.Conn
+
+	r          *bufio.Reader
+	remoteAddr net.Addr
+}
+
+// Read reads from the buffered reader.
+func (c *proxiedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// RemoteAddr returns the client address from the header.
+func (c *proxiedConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// readProxyHeader reads a version 1 or 2 header. A nil address is returned
+// for headers without a client address.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	// Every valid header is at least as long as the version 2 signature.
+	start, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(start, proxyV2Signature):
+		return readProxyV2Header(r)
+	case bytes.HasPrefix(start, []byte("PROXY")):
+		return readProxyV1Header(r)
+	default:
+		return nil, ErrInvalidProxyHeader
+	}
+}
+
+// readProxyV1Header reads a header like
+// "PROXY TCP4 192.0.2.1 198.51.100.1 56324 700\r\n".
+func readProxyV1Header(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+
+	for len(line) < proxyV1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		line = append(line, b)
+
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			break
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("%w: header too long", ErrInvalidProxyHeader)
+	}
+
+	fields := strings.Split(strings.TrimSuffix(string(line), "\r\n"), " ")
+	if len(fields) < 2 || fields[0] != "PROXY" {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	if fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProxyHeader, line)
+	}
+
+	ip, err := netip.ParseAddr(fields[2])
+	if err != nil || ip.Is4() != (fields[1] == "TCP4") {
+		return nil, fmt.Errorf("%w: invalid source address %q", ErrInvalidProxyHeader, fields[2])
+	}
+
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid source port %q", ErrInvalidProxyHeader, fields[4])
+	}
+
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(port))), nil
+}
+
+// readProxyV2Header reads a binary header. TLVs are skipped.
+func readProxyV2Header(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	versionCommand, family := header[12], header[13]
+	length := int(binary.BigEndian.Uint16(header[14:16]))
+
+	if versionCommand>>4 != 2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidProxyHeader, versionCommand>>4)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	switch versionCommand & 0x0f {
+	case 0x0:
+		// LOCAL, e.g. a health check from the proxy itself.
+		return nil, nil
+	case 0x1:
+		// PROXY.
+	default:
+		return nil, fmt.Errorf("%w: unsupported command %d", ErrInvalidProxyHeader, versionCommand&0x0f)
+	}
+
+	var size int
+
+	switch family {
+	case 0x11: // TCP over IPv4.
+		size = 4
+	case 0x21: // TCP over IPv6.
+		size = 16
+	default:
+		// Unspecified or unsupported protocol, the address is ignored.
+		return nil, nil
+	}
+
+	if length < 2*size+4 {
+		return nil, fmt.Errorf("%w: short address block", ErrInvalidProxyHeader)
+	}
+
+	ip, _ := netip.AddrFromSlice(payload[:size])
+	port := binary.BigEndian.Uint16(payload[2*size : 2*size+2])
+
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, port)), nil
+}
@@ -0,0 +1,210 @@
+package epplib
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func proxyV2Header(command, family byte, payload []byte) string {
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x20|command, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+
+	return string(append(header, payload...))
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	t.Parallel()
+
+	ipv4 := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0xdc, 0x04, 0x02, 0xbc}
+	ipv6 := append(netip.MustParseAddr("2001:db8::1").AsSlice(), netip.MustParseAddr("2001:db8::2").AsSlice()...)
+	ipv6 = append(ipv6, 0xdc, 0x04, 0x02, 0xbc)
+
+	for _, tc := range []struct {
+		name        string
+		header      string
+		expectAddr  string
+		expectError error
+	}{
+		{
+			name:       "v1 tcp4",
+			header:     "PROXY TCP4 192.0.2.1 198.51.100.1 56324 700\r\n",
+			expectAddr: "192.0.2.1:56324",
+		},
+		{
+			name:       "v1 tcp6",
+			header:     "PROXY TCP6 2001:db8::1 2001:db8::2 56324 700\r\n",
+			expectAddr: "[2001:db8::1]:56324",
+		},
+		{
+			name:   "v1 unknown",
+			header: "PROXY UNKNOWN\r\n",
+		},
+		{
+			name:        "v1 wrong family",
+			header:      "PROXY TCP4 2001:db8::1 2001:db8::2 56324 700\r\n",
+			expectError: ErrInvalidProxyHeader,
+		},
+		{
+			name:        "v1 invalid port",
+			header:      "PROXY TCP4 192.0.2.1 198.51.100.1 70000 700\r\n",
+			expectError: ErrInvalidProxyHeader,
+		},
+		{
+			name:        "v1 too long",
+			header:      "PROXY TCP4 " + strings.Repeat("1", proxyV1MaxLength) + "\r\n",
+			expectError: ErrInvalidProxyHeader,
+		},
+		{
+			name:       "v2 ipv4",
+			header:     proxyV2Header(0x1, 0x11, ipv4),
+			expectAddr: "192.0.2.1:56324",
+		},
+		{
+			name:       "v2 ipv6",
+			header:     proxyV2Header(0x1, 0x21, ipv6),
+			expectAddr: "[2001:db8::1]:56324",
+		},
+		{
+			name:       "v2 with tlvs",
+			header:     proxyV2Header(0x1, 0x11, append(ipv4, 0x02, 0x00, 0x03, 'f', 'o', 'o')),
+			expectAddr: "192.0.2.1:56324",
+		},
+		{
+			name:   "v2 local",
+			header: proxyV2Header(0x0, 0x00, nil),
+		},
+		{
+			name:        "v2 short address block",
+			header:      proxyV2Header(0x1, 0x21, ipv4),
+			expectError: ErrInvalidProxyHeader,
+		},
+		{
+			name:        "v2 unsupported command",
+			header:      proxyV2Header(0x2, 0x11, ipv4),
+			expectError: ErrInvalidProxyHeader,
+		},
+		{
+			name:        "no header",
+			header:      "\x16\x03\x01\x02\x00\x01\x00\x01\xfc\x03\x03\x00",
+			expectError: ErrInvalidProxyHeader,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := bufio.NewReader(strings.NewReader(tc.header + "rest"))
+
+			addr, err := readProxyHeader(r)
+			if tc.expectError != nil {
+				require.ErrorIs(t, err, tc.expectError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tc.expectAddr == "" {
+				assert.Nil(t, addr)
+			} else {
+				require.NotNil(t, addr)
+				assert.Equal(t, tc.expectAddr, addr.String())
+			}
+
+			// The data after the header is left unread.
+			rest, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "rest", string(rest))
+		})
+	}
+}
+
+func TestProxyProtocol_ReadHeader(t *testing.T) {
+	t.Parallel()
+
+	p := &ProxyProtocol{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	go func() {
+		_, err := io.WriteString(client, "PROXY TCP4 192.0.2.1 198.51.100.1 56324 700\r\nrest")
+		assert.NoError(t, err)
+	}()
+
+	c := &eppConn{conn: server}
+
+	conn, err := c.readProxyHeader(p, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.1:56324", conn.RemoteAddr().String())
+
+	rest := make([]byte, 4)
+	_, err = io.ReadFull(conn, rest)
+	require.NoError(t, err)
+	assert.Equal(t, "rest", string(rest))
+
+	// A proxy that doesn't send a header times out.
+	server, client = net.Pipe()
+	defer client.Close()
+
+	c = &eppConn{conn: server}
+
+	_, err = c.readProxyHeader(p, 10*time.Millisecond)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	// Waiting for the header is interrupted by stopAwaitMessage.
+	server, client = net.Pipe()
+	defer client.Close()
+
+	c = &eppConn{conn: server}
+
+	go func() {
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&c.isAwaitingMsg) == 1
+		}, time.Second, time.Millisecond)
+		assert.NoError(t, c.stopAwaitMessage())
+	}()
+
+	_, err = c.readProxyHeader(p, time.Minute)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	_, err = c.readProxyHeader(p, time.Minute)
+	require.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestProxyProtocol_HeaderTimeout(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Second, (&ProxyProtocol{HeaderTimeout: time.Second}).headerTimeout(time.Minute))
+	assert.Equal(t, time.Minute, (&ProxyProtocol{}).headerTimeout(time.Minute))
+	assert.Equal(t, defaultProxyHeaderTimeout, (&ProxyProtocol{}).headerTimeout(0))
+}
+
+func TestProxyProtocol_Trusted(t *testing.T) {
+	t.Parallel()
+
+	p := &ProxyProtocol{
+		TrustedProxies: []netip.Prefix{
+			netip.MustParsePrefix("192.0.2.0/24"),
+			netip.MustParsePrefix("2001:db8::/32"),
+		},
+	}
+
+	assert.True(t, p.trusted(&net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 700}))
+	assert.True(t, p.trusted(&net.TCPAddr{IP: net.ParseIP("::ffff:192.0.2.10"), Port: 700}))
+	assert.True(t, p.trusted(&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 700}))
+	assert.False(t, p.trusted(&net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 700}))
+	assert.False(t, p.trusted(&net.UnixAddr{Name: "/tmp/epp.sock", Net: "unix"}))
+}
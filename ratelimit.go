@@ -0,0 +1,312 @@
+package epplib
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// RateLimitKeyFunc returns the key commands are rate limited by. Commands with
+// an empty key aren't rate limited.
+type RateLimitKeyFunc func(ctx context.Context) string
+
+// RateLimitByRemoteIP rate limits commands by the remote IP of the session.
+func RateLimitByRemoteIP(ctx context.Context) string {
+	session := SessionFromContext(ctx)
+	if session == nil || session.RemoteAddr() == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(session.RemoteAddr().String())
+	if err != nil {
+		return session.RemoteAddr().String()
+	}
+
+	return host
+}
+
+// RateLimitByCertificate rate limits commands by the fingerprint of the client
+// certificate of the session.
+func RateLimitByCertificate(ctx context.Context) string {
+	session := SessionFromContext(ctx)
+	if session == nil {
+		return ""
+	}
+
+	certs := session.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return ""
+	}
+
+	return CertificateFingerprint(certs[0])
+}
+
+// RateLimitByClientID rate limits commands by the client id of the logged in
+// session. Commands before login aren't rate limited.
+func RateLimitByClientID(ctx context.Context) string {
+	session := SessionFromContext(ctx)
+	if session == nil || !session.LoggedIn() {
+		return ""
+	}
+
+	return session.ClientID()
+}
+
+// RateLimitAction is what the RateLimiter does with commands over the limit.
+type RateLimitAction int
+
+// Rate limit actions.
+const (
+	// RateLimitDelay delays the command until it is within the limit.
+	RateLimitDelay RateLimitAction = iota
+	// RateLimitReject replies 2400 with the reason "Rate limit exceeded".
+	RateLimitReject
+	// RateLimitClose replies 2502 and closes the connection.
+	RateLimitClose
+)
+
+// RateLimit is a token bucket budget of Rate commands per second with bursts
+// of up to Burst commands. A zero Rate means no limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimiter is a token bucket rate limiter middleware. Every key, e.g. the
+// remote IP or client id, has a bucket per command type. Register CloseConn as,
+// or call it from, the CloseConnHook of the Server to clean up the buckets when
+// the last connection of a key is closed. A RateLimiter is safe for concurrent
+// use.
+type RateLimiter struct {
+	// Key returns the key commands are rate limited by.
+	Key RateLimitKeyFunc
+
+	// Limits are the budgets per command type. The command type is either
+	// the command name prefixed by the object namespace prefix, e.g.
+	// "domain:check", or only the command name, e.g. "check" or "poll", and
+	// the prefixed name is preferred.
+	Limits map[string]RateLimit
+
+	// Default is the budget shared by the command types without a limit in
+	// Limits.
+	Default RateLimit
+
+	// Action is what to do with commands over the limit.
+	Action RateLimitAction
+
+	// now returns the current time, time.Now is used if not set.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*rateLimitEntry
+	keys    map[*Session]string
+}
+
+// rateLimitEntry holds the buckets of a key and the sessions using it.
+type rateLimitEntry struct {
+	sessions map[*Session]struct{}
+	buckets  map[string]*tokenBucket
+}
+
+// tokenBucket is a token bucket that is refilled when it is used.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens for the time since the bucket was last used.
+func (b *tokenBucket) refill(now time.Time, rate, burst float64) {
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+}
+
+// Middleware rate limits the commands of the next CommandFunc.
+func (l *RateLimiter) Middleware(next CommandFunc) CommandFunc {
+	return func(ctx context.Context, rw Writer, doc *etree.Document) {
+		key := l.Key(ctx)
+		if key == "" {
+			next(ctx, rw, doc)
+			return
+		}
+
+		info := ParseCommandInfo(doc)
+		commandType, limit := l.limit(info)
+
+		if limit.Rate <= 0 {
+			next(ctx, rw, doc)
+			return
+		}
+
+		wait := l.reserve(SessionFromContext(ctx), key, commandType, limit)
+		if wait <= 0 {
+			next(ctx, rw, doc)
+			return
+		}
+
+		slog.InfoContext(ctx, "rate limit exceeded",
+			slog.String("key", key),
+			slog.String("command", commandType),
+			slog.Duration("wait", wait),
+		)
+
+		switch l.Action {
+		case RateLimitDelay:
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+				next(ctx, rw, doc)
+			case <-ctx.Done():
+				rw.CloseAfterWrite()
+			}
+		case RateLimitReject:
+			writeRateLimitResponse(ctx, rw, info.ClientTransactionID,
+				NewError(StatusCommandFailed).WithExtValues(ExtValue{Reason: "Rate limit exceeded"}),
+			)
+		case RateLimitClose:
+			writeRateLimitResponse(ctx, rw, info.ClientTransactionID,
+				NewError(StatusSessionLimitExceededClosingConnection),
+			)
+			rw.CloseAfterWrite()
+		}
+	}
+}
+
+// CloseConn removes the session of the connection from the rate limiter and
+// the buckets of its key if it was the last session using it. It has the
+// signature of the CloseConnHook of the Server.
+func (l *RateLimiter) CloseConn(ctx context.Context, _ *tls.Conn) {
+	session := SessionFromContext(ctx)
+	if session == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.removeSession(session)
+}
+
+// limit returns the command type and budget for the command.
+func (l *RateLimiter) limit(info CommandInfo) (string, RateLimit) {
+	if info.Namespace != "" {
+		commandType := namespacePrefix(info.Namespace) + ":" + info.Name
+		if limit, ok := l.Limits[commandType]; ok {
+			return commandType, limit
+		}
+	}
+
+	if limit, ok := l.Limits[info.Name]; ok {
+		return info.Name, limit
+	}
+
+	return "", l.Default
+}
+
+// reserve takes a token from the bucket of the key and command type and
+// returns how long to wait until the token is available. If the token isn't
+// available it is reserved only for RateLimitDelay.
+func (l *RateLimiter) reserve(session *Session, key, commandType string, limit RateLimit) time.Duration {
+	now := time.Now()
+	if l.now != nil {
+		now = l.now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := l.entry(session, key)
+
+	burst := math.Max(float64(limit.Burst), 1)
+
+	bucket, ok := entry.buckets[commandType]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		entry.buckets[commandType] = bucket
+	}
+
+	bucket.refill(now, limit.Rate, burst)
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second))
+
+	if l.Action == RateLimitDelay {
+		bucket.tokens--
+	}
+
+	return wait
+}
+
+// entry returns the entry for the key, creating it if needed, and records the
+// session as using it. Must be called with mu held.
+func (l *RateLimiter) entry(session *Session, key string) *rateLimitEntry {
+	if l.entries == nil {
+		l.entries = make(map[string]*rateLimitEntry)
+		l.keys = make(map[*Session]string)
+	}
+
+	if session != nil {
+		if previous, ok := l.keys[session]; ok && previous != key {
+			// The key of the session has changed, e.g. after login.
+			l.removeSession(session)
+		}
+	}
+
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &rateLimitEntry{
+			sessions: make(map[*Session]struct{}),
+			buckets:  make(map[string]*tokenBucket),
+		}
+		l.entries[key] = entry
+	}
+
+	if session != nil {
+		entry.sessions[session] = struct{}{}
+		l.keys[session] = key
+	}
+
+	return entry
+}
+
+// removeSession removes the session from the entry of its key and the entry
+// if it isn't used anymore. Must be called with mu held.
+func (l *RateLimiter) removeSession(session *Session) {
+	key, ok := l.keys[session]
+	if !ok {
+		return
+	}
+
+	delete(l.keys, session)
+
+	entry := l.entries[key]
+	delete(entry.sessions, session)
+
+	if len(entry.sessions) == 0 {
+		delete(l.entries, key)
+	}
+}
+
+func writeRateLimitResponse(ctx context.Context, rw Writer, clTRID string, result *EppError) {
+	rw.Reset()
+
+	if err := WriteErrorResponse(rw, clTRID, NewServerTransactionID(), result); err != nil {
+		slog.ErrorContext(ctx, "could not write rate limit response",
+			slog.Any("err", err),
+		)
+
+		rw.Reset()
+		rw.CloseAfterWrite()
+	}
+}
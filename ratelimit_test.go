@@ -0,0 +1,208 @@
+package epplib
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rateLimitDomainCheck = `<domain:check xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"/>`
+	rateLimitDomainInfo  = `<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"/>`
+	rateLimitHostCheck   = `<host:check xmlns:host="urn:ietf:params:xml:ns:host-1.0"/>`
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		action      RateLimitAction
+		expectCode  string
+		expectClose bool
+	}{
+		{
+			name:       "reject",
+			action:     RateLimitReject,
+			expectCode: "2400",
+		},
+		{
+			name:        "close",
+			action:      RateLimitClose,
+			expectCode:  "2502",
+			expectClose: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Now()
+
+			limiter := &RateLimiter{
+				Key: RateLimitByClientID,
+				Limits: map[string]RateLimit{
+					"domain:check": {Rate: 1, Burst: 2},
+					"check":        {Rate: 10, Burst: 10},
+				},
+				Action: tc.action,
+				now:    func() time.Time { return now },
+			}
+
+			var handled int
+
+			handler := limiter.Middleware(func(context.Context, Writer, *etree.Document) {
+				handled++
+			})
+
+			session := &Session{}
+			ctx := ContextWithSession(context.Background(), session)
+
+			// Commands before login aren't limited by client id.
+			for range 5 {
+				handler(ctx, &ResponseWriter{}, commandDocument(t, "check", rateLimitDomainCheck, ""))
+			}
+
+			assert.Equal(t, 5, handled)
+
+			require.NoError(t, session.Login("ClientX", "en", nil, nil))
+
+			for range 2 {
+				rw := &ResponseWriter{}
+				handler(ctx, rw, commandDocument(t, "check", rateLimitDomainCheck, ""))
+				assert.Zero(t, rw.Len())
+			}
+
+			assert.Equal(t, 7, handled)
+
+			rw := &ResponseWriter{}
+			handler(ctx, rw, commandDocument(t, "check", rateLimitDomainCheck, ""))
+			assert.Equal(t, 7, handled)
+			assert.Equal(t, tc.expectClose, rw.ShouldCloseAfterWrite())
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromBytes(rw.Bytes()))
+			assert.Equal(t, tc.expectCode, doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+			assert.Equal(t, "ABC-12345", doc.FindElement("/epp/response/trID/clTRID").Text())
+
+			if tc.action == RateLimitReject {
+				assert.Equal(t, "Rate limit exceeded", doc.FindElement("/epp/response/result/extValue/reason").Text())
+			}
+
+			// Other command types have their own budget.
+			handler(ctx, &ResponseWriter{}, commandDocument(t, "check", rateLimitHostCheck, ""))
+			handler(ctx, &ResponseWriter{}, commandDocument(t, "info", rateLimitDomainInfo, ""))
+			assert.Equal(t, 9, handled)
+
+			// The bucket is refilled over time.
+			now = now.Add(time.Second)
+
+			handler(ctx, &ResponseWriter{}, commandDocument(t, "check", rateLimitDomainCheck, ""))
+			assert.Equal(t, 10, handled)
+
+			// Other clients have their own buckets.
+			other := &Session{}
+			require.NoError(t, other.Login("ClientY", "en", nil, nil))
+
+			handler(ContextWithSession(context.Background(), other), &ResponseWriter{}, commandDocument(t, "check", rateLimitDomainCheck, ""))
+			assert.Equal(t, 11, handled)
+		})
+	}
+}
+
+func TestRateLimiter_Delay(t *testing.T) {
+	t.Parallel()
+
+	limiter := &RateLimiter{
+		Key:     RateLimitByRemoteIP,
+		Default: RateLimit{Rate: 20, Burst: 1},
+		Action:  RateLimitDelay,
+	}
+
+	var handled int
+
+	handler := limiter.Middleware(func(context.Context, Writer, *etree.Document) {
+		handled++
+	})
+
+	ctx := ContextWithSession(context.Background(), &Session{
+		remoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 700},
+	})
+
+	start := time.Now()
+
+	for range 3 {
+		handler(ctx, &ResponseWriter{}, commandDocument(t, "check", rateLimitDomainCheck, ""))
+	}
+
+	assert.Equal(t, 3, handled)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// A canceled context stops the delay and closes the connection.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	rw := &ResponseWriter{}
+	handler(canceled, rw, commandDocument(t, "check", rateLimitDomainCheck, ""))
+	assert.Equal(t, 3, handled)
+	assert.True(t, rw.ShouldCloseAfterWrite())
+}
+
+func TestRateLimiter_CloseConn(t *testing.T) {
+	t.Parallel()
+
+	limiter := &RateLimiter{
+		Key:     RateLimitByClientID,
+		Default: RateLimit{Rate: 1, Burst: 1},
+		Action:  RateLimitReject,
+	}
+
+	handler := limiter.Middleware(func(context.Context, Writer, *etree.Document) {})
+
+	first := &Session{}
+	require.NoError(t, first.Login("ClientX", "en", nil, nil))
+
+	second := &Session{}
+	require.NoError(t, second.Login("ClientX", "en", nil, nil))
+
+	handler(ContextWithSession(context.Background(), first), &ResponseWriter{}, commandDocument(t, "check", rateLimitDomainCheck, ""))
+	handler(ContextWithSession(context.Background(), second), &ResponseWriter{}, commandDocument(t, "check", rateLimitDomainCheck, ""))
+
+	require.Len(t, limiter.entries, 1)
+
+	// The buckets are shared and kept until the last session is closed.
+	limiter.CloseConn(ContextWithSession(context.Background(), first), nil)
+	require.Len(t, limiter.entries, 1)
+
+	limiter.CloseConn(ContextWithSession(context.Background(), second), nil)
+	assert.Empty(t, limiter.entries)
+	assert.Empty(t, limiter.keys)
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", RateLimitByRemoteIP(context.Background()))
+	assert.Equal(t, "", RateLimitByCertificate(context.Background()))
+	assert.Equal(t, "", RateLimitByClientID(context.Background()))
+
+	cert := &x509.Certificate{Raw: []byte("certificate")}
+	session := &Session{
+		remoteAddr: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 700},
+	}
+	session.connState.PeerCertificates = []*x509.Certificate{cert}
+
+	ctx := ContextWithSession(context.Background(), session)
+
+	assert.Equal(t, "2001:db8::1", RateLimitByRemoteIP(ctx))
+	assert.Equal(t, CertificateFingerprint(cert), RateLimitByCertificate(ctx))
+	assert.Equal(t, "", RateLimitByClientID(ctx))
+
+	require.NoError(t, session.Login("ClientX", "en", nil, nil))
+	assert.Equal(t, "ClientX", RateLimitByClientID(ctx))
+}
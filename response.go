@@ -0,0 +1,353 @@
+package epplib
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+var responsePath = etree.MustCompilePath(NewXMLPathBuilder().
+	Add("epp", NamespaceIETFEPP10.String()).
+	Add("response", NamespaceIETFEPP10.String()).String())
+
+// MsgQ represent the msgQ element of a response. QDate and Msg are only
+// written if set.
+type MsgQ struct {
+	Count int
+	ID    string
+	QDate time.Time
+	Msg   string
+	Lang  string
+}
+
+// ResponseBuilder builds an EPP response. The elements are always written in
+// the order required by RFC 5730 regardless of the order they are added.
+type ResponseBuilder struct {
+	results    []*EppError
+	msgQ       *MsgQ
+	resData    []*etree.Element
+	extensions []*etree.Element
+	clTRID     string
+	svTRID     string
+}
+
+// NewResponseBuilder creates a new response builder.
+func NewResponseBuilder() *ResponseBuilder {
+	return &ResponseBuilder{}
+}
+
+// WithResults add results to the response. If no result is added the response
+// will have a single StatusSuccess result.
+func (b *ResponseBuilder) WithResults(results ...*EppError) *ResponseBuilder {
+	b.results = append(b.results, results...)
+	return b
+}
+
+// WithMsgQ set the msgQ of the response.
+func (b *ResponseBuilder) WithMsgQ(msgQ MsgQ) *ResponseBuilder {
+	b.msgQ = &msgQ
+	return b
+}
+
+// WithResData add children to the resData element of the response. The
+// elements must declare their own namespaces.
+func (b *ResponseBuilder) WithResData(elements ...*etree.Element) *ResponseBuilder {
+	b.resData = append(b.resData, elements...)
+	return b
+}
+
+// WithExtension add children to the extension element of the response. The
+// elements must declare their own namespaces.
+func (b *ResponseBuilder) WithExtension(elements ...*etree.Element) *ResponseBuilder {
+	b.extensions = append(b.extensions, elements...)
+	return b
+}
+
+// WithTransactionID set the client and server transaction ids.
+func (b *ResponseBuilder) WithTransactionID(clTRID, svTRID string) *ResponseBuilder {
+	b.clTRID = clTRID
+	b.svTRID = svTRID
+
+	return b
+}
+
+// Document builds the response document.
+func (b *ResponseBuilder) Document() *etree.Document {
+	doc, response := newResponseDocument()
+
+	results := b.results
+	if len(results) == 0 {
+		results = []*EppError{NewError(StatusSuccess)}
+	}
+
+	for _, result := range results {
+		createResultElement(response, result)
+	}
+
+	if b.msgQ != nil {
+		createMsgQElement(response, b.msgQ)
+	}
+
+	if len(b.resData) > 0 {
+		resData := response.CreateElement("resData")
+
+		for _, el := range b.resData {
+			resData.AddChild(el.Copy())
+		}
+	}
+
+	if len(b.extensions) > 0 {
+		extension := response.CreateElement("extension")
+
+		for _, el := range b.extensions {
+			extension.AddChild(el.Copy())
+		}
+	}
+
+	createTransactionIDElement(response, b.clTRID, b.svTRID)
+
+	return doc
+}
+
+// WriteTo writes the response document on w.
+func (b *ResponseBuilder) WriteTo(w io.Writer) (int64, error) {
+	return b.Document().WriteTo(w)
+}
+
+// WriteErrorResponse writes an EPP response with one result per given error on
+// w. The clTRID is optional but the svTRID is required by RFC 5730.
+func WriteErrorResponse(w io.Writer, clTRID, svTRID string, errs ...*EppError) error {
+	_, err := NewResponseBuilder().
+		WithResults(errs...).
+		WithTransactionID(clTRID, svTRID).
+		WriteTo(w)
+
+	return err
+}
+
+// ParseResults returns the results of the response in doc.
+func ParseResults(doc *etree.Document) []*EppError {
+	response := doc.FindElementPath(responsePath)
+	if response == nil {
+		return nil
+	}
+
+	var results []*EppError
+
+	for _, el := range response.ChildElements() {
+		if el.Tag != "result" || el.NamespaceURI() != NamespaceIETFEPP10.String() {
+			continue
+		}
+
+		results = append(results, parseResultElement(el))
+	}
+
+	return results
+}
+
+func parseResultElement(el *etree.Element) *EppError {
+	code, _ := strconv.Atoi(el.SelectAttrValue("code", ""))
+	result := &EppError{Code: code}
+
+	for _, child := range el.ChildElements() {
+		switch child.Tag {
+		case "msg":
+			result.Message = child.Text()
+			result.Lang = child.SelectAttrValue("lang", "")
+		case "value":
+			element, namespace, value := parseValueElement(child)
+			result.Values = append(result.Values, Value{
+				Element:   element,
+				Value:     value,
+				Namespace: namespace,
+			})
+		case "extValue":
+			extValue := ExtValue{}
+
+			if value := child.SelectElement("value"); value != nil {
+				extValue.Element, extValue.Namespace, extValue.Value = parseValueElement(value)
+			}
+
+			if reason := child.SelectElement("reason"); reason != nil {
+				extValue.Reason = reason.Text()
+				extValue.Lang = reason.SelectAttrValue("lang", "")
+			}
+
+			result.ExtValues = append(result.ExtValues, extValue)
+		}
+	}
+
+	return result
+}
+
+func parseValueElement(value *etree.Element) (element, namespace, text string) {
+	children := value.ChildElements()
+	if len(children) == 0 {
+		return "", "", value.Text()
+	}
+
+	if children[0].Tag == "undef" && children[0].NamespaceURI() == NamespaceIETFEPP10.String() {
+		return "", "", ""
+	}
+
+	namespace = children[0].NamespaceURI()
+	if namespace == NamespaceIETFEPP10.String() {
+		namespace = ""
+	}
+
+	return children[0].Tag, namespace, children[0].Text()
+}
+
+// NewServerTransactionID returns a random server transaction id.
+func NewServerTransactionID() string {
+	return NewTransactionID()
+}
+
+// NewTransactionID returns a random transaction id that can be used both as a
+// client and a server transaction id.
+func NewTransactionID() string {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		// It should be safe to panic here since reading from crypto/rand
+		// only fails if the system is broken.
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// newResponseDocument creates a new document with the epp root and an empty
+// response element.
+func newResponseDocument() (*etree.Document, *etree.Element) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8" standalone="no"`)
+
+	epp := doc.CreateElement("epp")
+	epp.CreateAttr("xmlns", NamespaceIETFEPP10.String())
+
+	return doc, epp.CreateElement("response")
+}
+
+func createResultElement(parent *etree.Element, err *EppError) {
+	result := parent.CreateElement("result")
+	result.CreateAttr("code", strconv.Itoa(err.Code))
+
+	msg := result.CreateElement("msg")
+	if err.Lang != "" {
+		msg.CreateAttr("lang", err.Lang)
+	}
+
+	message := err.Message
+	if message == "" {
+		message = StatusText(err.Code)
+	}
+
+	msg.SetText(message)
+
+	for _, v := range err.Values {
+		createValueElement(result.CreateElement("value"), v.Element, v.Namespace, v.Value)
+	}
+
+	for _, v := range err.ExtValues {
+		extValue := result.CreateElement("extValue")
+		createValueElement(extValue.CreateElement("value"), v.Element, v.Namespace, v.Value)
+
+		reason := extValue.CreateElement("reason")
+		if v.Lang != "" {
+			reason.CreateAttr("lang", v.Lang)
+		}
+
+		reason.SetText(v.Reason)
+	}
+}
+
+// createValueElement adds the offending element to a value element. Elements
+// outside of the EPP namespace get their namespace declared on the value
+// element. An empty element is written as <undef/> as described in RFC 5730.
+func createValueElement(value *etree.Element, element, namespace, text string) {
+	if i := strings.LastIndexByte(element, ':'); i >= 0 {
+		element = element[i+1:]
+	}
+
+	if element == "" {
+		value.CreateElement("undef")
+		return
+	}
+
+	if namespace == "" || namespace == NamespaceIETFEPP10.String() {
+		value.CreateElement(element).SetText(text)
+		return
+	}
+
+	prefix := namespacePrefix(namespace)
+
+	value.CreateAttr("xmlns:"+prefix, namespace)
+	value.CreateElement(prefix + ":" + element).SetText(text)
+}
+
+func createMsgQElement(parent *etree.Element, msgQ *MsgQ) {
+	el := parent.CreateElement("msgQ")
+	el.CreateAttr("count", strconv.Itoa(msgQ.Count))
+	el.CreateAttr("id", msgQ.ID)
+
+	if !msgQ.QDate.IsZero() {
+		el.CreateElement("qDate").SetText(formatDateTime(msgQ.QDate))
+	}
+
+	if msgQ.Msg != "" {
+		msg := el.CreateElement("msg")
+		if msgQ.Lang != "" {
+			msg.CreateAttr("lang", msgQ.Lang)
+		}
+
+		msg.SetText(msgQ.Msg)
+	}
+}
+
+func createTransactionIDElement(parent *etree.Element, clTRID, svTRID string) {
+	trID := parent.CreateElement("trID")
+
+	if clTRID != "" {
+		trID.CreateElement("clTRID").SetText(clTRID)
+	}
+
+	trID.CreateElement("svTRID").SetText(svTRID)
+}
+
+// namespacePrefix returns a prefix suitable for the namespace. It is the
+// prefix registered in defaultNamespaceRegistry or, for unknown namespaces,
+// the prefix derived by defaultNamespacePrefix.
+func namespacePrefix(ns string) string {
+	if info, ok := defaultNamespaceRegistry.infoByURI(ns); ok {
+		return info.Prefix
+	}
+
+	return defaultNamespacePrefix(ns)
+}
+
+// defaultNamespacePrefix returns the last part of the namespace without
+// version, e.g. "domain" for urn:ietf:params:xml:ns:domain-1.0.
+func defaultNamespacePrefix(ns string) string {
+	if i := strings.LastIndexAny(ns, ":/"); i >= 0 {
+		ns = ns[i+1:]
+	}
+
+	if i := strings.LastIndexByte(ns, '-'); i > 0 {
+		if _, err := strconv.ParseFloat(ns[i+1:], 64); err == nil {
+			ns = ns[:i]
+		}
+	}
+
+	return ns
+}
+
+// formatDateTime formats t as an XML Schema dateTime in UTC.
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,195 @@
+package epplib
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	err := WriteErrorResponse(&buf, "ABC-12345", "54321-XYZ",
+		NewError(StatusObjectDoesNotExist).WithValues(Value{
+			Element:   "domain:name",
+			Value:     "a&b.se",
+			Namespace: NamespaceIETFDomain10.String(),
+		}),
+		(&EppError{
+			Code:    StatusParameterPolicyError,
+			Message: "Ogiltig parameter",
+			Lang:    "sv",
+		}).WithExtValues(ExtValue{
+			Element:   "registrant",
+			Value:     "<ABC123>",
+			Namespace: NamespaceIETFDomain10.String(),
+			Reason:    "Kontakten finns inte",
+			Lang:      "sv",
+		}),
+		NewError(StatusMissingParameter).WithValues(Value{}),
+	)
+	require.NoError(t, err)
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromBytes(buf.Bytes()))
+
+	assert.Equal(t, NamespaceIETFEPP10.String(), doc.Root().NamespaceURI())
+
+	results := doc.FindElements("/epp/response/result")
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "2303", results[0].SelectAttrValue("code", ""))
+	assert.Equal(t, "Object does not exist", results[0].SelectElement("msg").Text())
+	assert.Nil(t, results[0].SelectElement("msg").SelectAttr("lang"))
+
+	value := results[0].FindElement("value/name")
+	require.NotNil(t, value)
+	assert.Equal(t, NamespaceIETFDomain10.String(), value.NamespaceURI())
+	assert.Equal(t, "a&b.se", value.Text())
+	assert.Equal(t, NamespaceIETFDomain10.String(), results[0].SelectElement("value").SelectAttrValue("xmlns:domain", ""))
+
+	assert.Equal(t, "2306", results[1].SelectAttrValue("code", ""))
+	assert.Equal(t, "sv", results[1].SelectElement("msg").SelectAttrValue("lang", ""))
+	assert.Equal(t, "Ogiltig parameter", results[1].SelectElement("msg").Text())
+
+	extValue := results[1].FindElement("extValue/value/registrant")
+	require.NotNil(t, extValue)
+	assert.Equal(t, NamespaceIETFDomain10.String(), extValue.NamespaceURI())
+	assert.Equal(t, "<ABC123>", extValue.Text())
+
+	reason := results[1].FindElement("extValue/reason")
+	require.NotNil(t, reason)
+	assert.Equal(t, "sv", reason.SelectAttrValue("lang", ""))
+	assert.Equal(t, "Kontakten finns inte", reason.Text())
+
+	assert.NotNil(t, results[2].FindElement("value/undef"))
+
+	assert.Equal(t, "ABC-12345", doc.FindElement("/epp/response/trID/clTRID").Text())
+	assert.Equal(t, "54321-XYZ", doc.FindElement("/epp/response/trID/svTRID").Text())
+}
+
+func TestNamespacePrefix(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "domain", namespacePrefix(NamespaceIETFDomain10.String()))
+	assert.Equal(t, "secDNS", namespacePrefix(NamespaceIETFSecDNS11.String()))
+	assert.Equal(t, "iis", namespacePrefix(NamespaceIISEpp12.String()))
+	assert.Equal(t, "registryLock", namespacePrefix(NamespaceIISRegistryLock10.String()))
+	assert.Equal(t, "XMLSchema-instance", namespacePrefix(NamespaceW3XSI.String()))
+}
+
+func TestResponseBuilder(t *testing.T) {
+	t.Parallel()
+
+	chkData := etree.NewElement("domain:chkData")
+	chkData.CreateAttr("xmlns:domain", NamespaceIETFDomain10.String())
+	chkData.CreateElement("domain:cd").CreateElement("domain:name").SetText("example.se")
+
+	secDNS := etree.NewElement("secDNS:infData")
+	secDNS.CreateAttr("xmlns:secDNS", NamespaceIETFSecDNS11.String())
+
+	iis := etree.NewElement("iis:infData")
+	iis.CreateAttr("xmlns:iis", NamespaceIISEpp12.String())
+
+	qDate := time.Date(2000, 6, 8, 22, 0, 0, 0, time.UTC)
+
+	doc := NewResponseBuilder().
+		WithTransactionID("ABC-12345", "54322-XYZ").
+		WithExtension(secDNS, iis).
+		WithResData(chkData).
+		WithMsgQ(MsgQ{
+			Count: 5,
+			ID:    "12345",
+			QDate: qDate,
+			Msg:   "Transfer requested.",
+			Lang:  "en",
+		}).
+		WithResults(NewError(StatusAckToDequeue)).
+		Document()
+
+	response := doc.FindElement("/epp/response")
+	require.NotNil(t, response)
+
+	var order []string
+
+	for _, el := range response.ChildElements() {
+		order = append(order, el.Tag)
+	}
+
+	assert.Equal(t, []string{"result", "msgQ", "resData", "extension", "trID"}, order)
+
+	assert.Equal(t, "1301", response.FindElement("result").SelectAttrValue("code", ""))
+
+	msgQ := response.SelectElement("msgQ")
+	assert.Equal(t, "5", msgQ.SelectAttrValue("count", ""))
+	assert.Equal(t, "12345", msgQ.SelectAttrValue("id", ""))
+	assert.Equal(t, "2000-06-08T22:00:00Z", msgQ.SelectElement("qDate").Text())
+	assert.Equal(t, "Transfer requested.", msgQ.SelectElement("msg").Text())
+	assert.Equal(t, "en", msgQ.SelectElement("msg").SelectAttrValue("lang", ""))
+
+	name := response.FindElement("resData/chkData/cd/name")
+	require.NotNil(t, name)
+	assert.Equal(t, NamespaceIETFDomain10.String(), name.NamespaceURI())
+	assert.Equal(t, "example.se", name.Text())
+
+	extensions := response.SelectElement("extension").ChildElements()
+	require.Len(t, extensions, 2)
+	assert.Equal(t, NamespaceIETFSecDNS11.String(), extensions[0].NamespaceURI())
+	assert.Equal(t, NamespaceIISEpp12.String(), extensions[1].NamespaceURI())
+
+	// The builder should not take ownership of the added elements.
+	assert.Nil(t, chkData.Parent())
+}
+
+func TestResponseBuilder_Defaults(t *testing.T) {
+	t.Parallel()
+
+	doc := NewResponseBuilder().WithTransactionID("", "SV-1").Document()
+
+	results := doc.FindElements("/epp/response/result")
+	require.Len(t, results, 1)
+	assert.Equal(t, "1000", results[0].SelectAttrValue("code", ""))
+	assert.Nil(t, doc.FindElement("/epp/response/msgQ"))
+	assert.Nil(t, doc.FindElement("/epp/response/resData"))
+	assert.Nil(t, doc.FindElement("/epp/response/extension"))
+	assert.Nil(t, doc.FindElement("/epp/response/trID/clTRID"))
+	assert.Equal(t, "SV-1", doc.FindElement("/epp/response/trID/svTRID").Text())
+}
+
+func TestParseResults(t *testing.T) {
+	t.Parallel()
+
+	expected := []*EppError{
+		NewError(StatusObjectDoesNotExist).WithValues(Value{
+			Element:   "name",
+			Value:     "a&b.se",
+			Namespace: NamespaceIETFDomain10.String(),
+		}),
+		(&EppError{
+			Code:    StatusParameterPolicyError,
+			Message: "Ogiltig parameter",
+			Lang:    "sv",
+		}).WithExtValues(ExtValue{
+			Element:   "registrant",
+			Value:     "ABC123",
+			Namespace: NamespaceIETFDomain10.String(),
+			Reason:    "Kontakten finns inte",
+			Lang:      "sv",
+		}),
+		NewError(StatusMissingParameter).WithValues(Value{}, Value{
+			Element: "clTRID",
+			Value:   "ABC-1",
+		}),
+	}
+
+	doc := NewResponseBuilder().WithResults(expected...).WithTransactionID("", "SV-1").Document()
+
+	assert.Equal(t, expected, ParseResults(doc))
+	assert.Empty(t, ParseResults(etree.NewDocument()))
+}
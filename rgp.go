@@ -0,0 +1,203 @@
+package epplib
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// RGP statuses as described in https://datatracker.ietf.org/doc/html/rfc3915#section-3.2
+const (
+	RGPStatusAddPeriod        = "addPeriod"
+	RGPStatusAutoRenewPeriod  = "autoRenewPeriod"
+	RGPStatusRenewPeriod      = "renewPeriod"
+	RGPStatusTransferPeriod   = "transferPeriod"
+	RGPStatusRedemptionPeriod = "redemptionPeriod"
+	RGPStatusPendingRestore   = "pendingRestore"
+	RGPStatusPendingDelete    = "pendingDelete"
+)
+
+// RGP restore operations.
+const (
+	RGPOpRequest = "request"
+	RGPOpReport  = "report"
+)
+
+// RGPRestore represent the restore element of an RGP update extension. Report
+// is only set when Op is RGPOpReport.
+type RGPRestore struct {
+	Op     string
+	Report *RGPReport
+}
+
+// RGPReport represent the report element of a restore report.
+type RGPReport struct {
+	PreData       string
+	PostData      string
+	DelTime       time.Time
+	ResTime       time.Time
+	ResReason     string
+	ResReasonLang string
+	Statements    []RGPStatement
+	Other         string
+}
+
+// RGPStatement represent a statement element of a restore report.
+type RGPStatement struct {
+	Text string
+	Lang string
+}
+
+// RGPStatus represent the rgpStatus element.
+type RGPStatus struct {
+	Status string
+	Lang   string
+	Text   string
+}
+
+// ParseRGPRestore parses the RGP update extension of a domain update command.
+// If the command has no RGP extension nil is returned.
+func ParseRGPRestore(doc *etree.Document) (*RGPRestore, error) {
+	ns := NamespaceIETFRGP10.String()
+
+	update := commandExtensionElement(doc, "update", ns)
+	if update == nil {
+		return nil, nil
+	}
+
+	restoreEl := childElement(update, "restore", ns)
+	if restoreEl == nil {
+		return nil, missingParameterError("restore", ns)
+	}
+
+	restore := &RGPRestore{
+		Op: restoreEl.SelectAttrValue("op", ""),
+	}
+
+	switch restore.Op {
+	case RGPOpRequest:
+	case RGPOpReport:
+		report := childElement(restoreEl, "report", ns)
+		if report == nil {
+			return nil, missingParameterError("report", ns)
+		}
+
+		var err error
+
+		if restore.Report, err = parseRGPReport(report); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, syntaxError("restore", ns, restore.Op)
+	}
+
+	return restore, nil
+}
+
+func parseRGPReport(el *etree.Element) (*RGPReport, error) {
+	var (
+		ns     = NamespaceIETFRGP10.String()
+		report = &RGPReport{
+			// preData and postData may be empty.
+			PreData:  childText(el, "preData", ns),
+			PostData: childText(el, "postData", ns),
+			Other:    childText(el, "other", ns),
+		}
+		err error
+	)
+
+	for _, tag := range []string{"preData", "postData"} {
+		if childElement(el, tag, ns) == nil {
+			return nil, missingParameterError(tag, ns)
+		}
+	}
+
+	if report.DelTime, err = parseRequiredDateTime(el, "delTime", ns); err != nil {
+		return nil, err
+	}
+
+	if report.ResTime, err = parseRequiredDateTime(el, "resTime", ns); err != nil {
+		return nil, err
+	}
+
+	resReason := childElement(el, "resReason", ns)
+	if resReason == nil || strings.TrimSpace(resReason.Text()) == "" {
+		return nil, missingParameterError("resReason", ns)
+	}
+
+	report.ResReason = strings.TrimSpace(resReason.Text())
+	report.ResReasonLang = resReason.SelectAttrValue("lang", "")
+
+	for _, statement := range childElements(el, "statement", ns) {
+		report.Statements = append(report.Statements, RGPStatement{
+			Text: strings.TrimSpace(statement.Text()),
+			Lang: statement.SelectAttrValue("lang", ""),
+		})
+	}
+
+	// One or two statements, e.g. that the information is factual and that
+	// the restore isn't for an illegitimate purpose.
+	if len(report.Statements) == 0 || slices.ContainsFunc(report.Statements, func(s RGPStatement) bool {
+		return s.Text == ""
+	}) {
+		return nil, missingParameterError("statement", ns)
+	}
+
+	if len(report.Statements) > 2 {
+		return nil, NewError(StatusCommandSyntaxError).WithValues(Value{
+			Element:   "statement",
+			Namespace: ns,
+		})
+	}
+
+	return report, nil
+}
+
+// parseRequiredDateTime is like parseDateTime but returns a 2003 error if the
+// element is missing.
+func parseRequiredDateTime(el *etree.Element, tag, ns string) (time.Time, error) {
+	if childText(el, tag, ns) == "" {
+		return time.Time{}, missingParameterError(tag, ns)
+	}
+
+	return parseDateTime(el, tag, ns)
+}
+
+// RGPInfData represent the infData element of a domain info response
+// extension.
+type RGPInfData struct {
+	Statuses []RGPStatus
+}
+
+// Element returns the infData element.
+func (d *RGPInfData) Element() *etree.Element {
+	return createRGPStatusElement("infData", d.Statuses...)
+}
+
+// RGPUpData represent the upData element of a domain update response
+// extension. It has a single rgpStatus.
+type RGPUpData struct {
+	Status RGPStatus
+}
+
+// Element returns the upData element.
+func (d *RGPUpData) Element() *etree.Element {
+	return createRGPStatusElement("upData", d.Status)
+}
+
+func createRGPStatusElement(tag string, statuses ...RGPStatus) *etree.Element {
+	el := newPrefixedElement(NamespaceIETFRGP10.String(), tag)
+
+	for _, status := range statuses {
+		s := createTextChild(el, "rgpStatus", status.Text)
+		s.CreateAttr("s", status.Status)
+
+		if status.Lang != "" {
+			s.CreateAttr("lang", status.Lang)
+		}
+	}
+
+	return el
+}
@@ -0,0 +1,153 @@
+package epplib
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRGPRestore(t *testing.T) {
+	t.Parallel()
+
+	const report = `<rgp:preData>Pre-delete registration data goes here.</rgp:preData>
+<rgp:postData>Post-restore registration data goes here.</rgp:postData>
+<rgp:delTime>2003-07-10T22:00:00.0Z</rgp:delTime>
+<rgp:resTime>2003-07-20T22:00:00.0Z</rgp:resTime>
+<rgp:resReason>Registrant error.</rgp:resReason>
+<rgp:statement>This registrar has not restored the Registered Name in order to assume the rights to use or sell the Registered Name for itself or for any third party.</rgp:statement>
+<rgp:statement lang="sv">Informationen är korrekt.</rgp:statement>`
+
+	for _, tc := range []struct {
+		name         string
+		extension    string
+		expected     *RGPRestore
+		expectedCode int
+		expectedElem string
+	}{
+		{
+			name: "no extension",
+		},
+		{
+			name:      "request",
+			extension: `<rgp:update xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:restore op="request"/></rgp:update>`,
+			expected:  &RGPRestore{Op: RGPOpRequest},
+		},
+		{
+			name:      "report",
+			extension: `<rgp:update xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:restore op="report"><rgp:report>` + report + `<rgp:other>Supporting information goes here.</rgp:other></rgp:report></rgp:restore></rgp:update>`,
+			expected: &RGPRestore{
+				Op: RGPOpReport,
+				Report: &RGPReport{
+					PreData:   "Pre-delete registration data goes here.",
+					PostData:  "Post-restore registration data goes here.",
+					DelTime:   time.Date(2003, 7, 10, 22, 0, 0, 0, time.UTC),
+					ResTime:   time.Date(2003, 7, 20, 22, 0, 0, 0, time.UTC),
+					ResReason: "Registrant error.",
+					Statements: []RGPStatement{
+						{Text: "This registrar has not restored the Registered Name in order to assume the rights to use or sell the Registered Name for itself or for any third party."},
+						{Text: "Informationen är korrekt.", Lang: "sv"},
+					},
+					Other: "Supporting information goes here.",
+				},
+			},
+		},
+		{
+			name:         "invalid op",
+			extension:    `<rgp:update xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:restore op="undo"/></rgp:update>`,
+			expectedCode: StatusValueSyntaxError,
+			expectedElem: "restore",
+		},
+		{
+			name:         "report without report",
+			extension:    `<rgp:update xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:restore op="report"/></rgp:update>`,
+			expectedCode: StatusMissingParameter,
+			expectedElem: "report",
+		},
+		{
+			name:         "invalid delTime",
+			extension:    `<rgp:update xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:restore op="report"><rgp:report><rgp:preData/><rgp:postData/><rgp:delTime>yesterday</rgp:delTime></rgp:report></rgp:restore></rgp:update>`,
+			expectedCode: StatusValueSyntaxError,
+			expectedElem: "delTime",
+		},
+		{
+			name:      "one statement",
+			extension: `<rgp:update xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:restore op="report"><rgp:report><rgp:preData/><rgp:postData/><rgp:delTime>2003-07-10T22:00:00Z</rgp:delTime><rgp:resTime>2003-07-20T22:00:00Z</rgp:resTime><rgp:resReason>Error.</rgp:resReason><rgp:statement>Statement.</rgp:statement></rgp:report></rgp:restore></rgp:update>`,
+			expected: &RGPRestore{
+				Op: RGPOpReport,
+				Report: &RGPReport{
+					DelTime:    time.Date(2003, 7, 10, 22, 0, 0, 0, time.UTC),
+					ResTime:    time.Date(2003, 7, 20, 22, 0, 0, 0, time.UTC),
+					ResReason:  "Error.",
+					Statements: []RGPStatement{{Text: "Statement."}},
+				},
+			},
+		},
+		{
+			name:         "no statement",
+			extension:    `<rgp:update xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:restore op="report"><rgp:report><rgp:preData/><rgp:postData/><rgp:delTime>2003-07-10T22:00:00Z</rgp:delTime><rgp:resTime>2003-07-20T22:00:00Z</rgp:resTime><rgp:resReason>Error.</rgp:resReason></rgp:report></rgp:restore></rgp:update>`,
+			expectedCode: StatusMissingParameter,
+			expectedElem: "statement",
+		},
+		{
+			name:         "three statements",
+			extension:    `<rgp:update xmlns:rgp="urn:ietf:params:xml:ns:rgp-1.0"><rgp:restore op="report"><rgp:report><rgp:preData/><rgp:postData/><rgp:delTime>2003-07-10T22:00:00Z</rgp:delTime><rgp:resTime>2003-07-20T22:00:00Z</rgp:resTime><rgp:resReason>Error.</rgp:resReason><rgp:statement>One.</rgp:statement><rgp:statement>Two.</rgp:statement><rgp:statement>Three.</rgp:statement></rgp:report></rgp:restore></rgp:update>`,
+			expectedCode: StatusCommandSyntaxError,
+			expectedElem: "statement",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := etree.NewDocument()
+			require.NoError(t, doc.ReadFromString(`<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><update><domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name><domain:chg/></domain:update></update><extension>`+tc.extension+`</extension></command></epp>`))
+
+			restore, err := ParseRGPRestore(doc)
+
+			if tc.expectedCode != 0 {
+				var eppErr *EppError
+
+				require.True(t, errors.As(err, &eppErr))
+				assert.Equal(t, tc.expectedCode, eppErr.Code)
+				require.Len(t, eppErr.Values, 1)
+				assert.Equal(t, tc.expectedElem, eppErr.Values[0].Element)
+				assert.Equal(t, NamespaceIETFRGP10.String(), eppErr.Values[0].Namespace)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, restore)
+		})
+	}
+}
+
+func TestRGPStatusData(t *testing.T) {
+	t.Parallel()
+
+	el := (&RGPInfData{
+		Statuses: []RGPStatus{
+			{Status: RGPStatusRedemptionPeriod},
+			{Status: RGPStatusPendingDelete, Lang: "en", Text: "Deleted by registrar."},
+		},
+	}).Element()
+
+	assert.Equal(t, "infData", el.Tag)
+	assert.Equal(t, NamespaceIETFRGP10.String(), el.SelectAttrValue("xmlns:rgp", ""))
+
+	statuses := el.SelectElements("rgpStatus")
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "redemptionPeriod", statuses[0].SelectAttrValue("s", ""))
+	assert.Nil(t, statuses[0].SelectAttr("lang"))
+	assert.Equal(t, "pendingDelete", statuses[1].SelectAttrValue("s", ""))
+	assert.Equal(t, "en", statuses[1].SelectAttrValue("lang", ""))
+	assert.Equal(t, "Deleted by registrar.", statuses[1].Text())
+
+	el = (&RGPUpData{Status: RGPStatus{Status: RGPStatusPendingRestore}}).Element()
+	assert.Equal(t, "upData", el.Tag)
+	require.Len(t, el.SelectElements("rgpStatus"), 1)
+	assert.Equal(t, "pendingRestore", el.SelectElement("rgpStatus").SelectAttrValue("s", ""))
+}
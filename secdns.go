@@ -0,0 +1,601 @@
+package epplib
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// SecDNSDSData represent the dsData element.
+type SecDNSDSData struct {
+	KeyTag     uint16
+	Alg        uint8
+	DigestType uint8
+	Digest     string
+	KeyData    *SecDNSKeyData
+}
+
+// SecDNSKeyData represent the keyData element. PubKey is base64 encoded
+// without whitespace.
+type SecDNSKeyData struct {
+	Flags    uint16
+	Protocol uint8
+	Alg      uint8
+	PubKey   string
+}
+
+// SecDNSCreate represent the secDNS-1.1 create extension. Only one of DSData
+// and KeyData is set. A MaxSigLife of 0 means that it isn't set.
+type SecDNSCreate struct {
+	MaxSigLife int
+	DSData     []SecDNSDSData
+	KeyData    []SecDNSKeyData
+}
+
+// SecDNSUpdate represent the secDNS-1.1 update extension. RemAll removes all
+// existing DS or key data before anything is added. A MaxSigLife of 0 means
+// that it isn't changed.
+type SecDNSUpdate struct {
+	Urgent     bool
+	RemAll     bool
+	RemDSData  []SecDNSDSData
+	RemKeyData []SecDNSKeyData
+	AddDSData  []SecDNSDSData
+	AddKeyData []SecDNSKeyData
+	MaxSigLife int
+}
+
+// SecDNS10DSData represent the secDNS-1.0 dsData element which has the
+// maxSigLife in every dsData.
+type SecDNS10DSData struct {
+	KeyTag     uint16
+	Alg        uint8
+	DigestType uint8
+	Digest     string
+	MaxSigLife int
+	KeyData    *SecDNSKeyData
+}
+
+// SecDNS10Create represent the secDNS-1.0 create extension.
+type SecDNS10Create struct {
+	DSData []SecDNS10DSData
+}
+
+// SecDNS10Update represent the secDNS-1.0 update extension. Only one of Add,
+// Chg and RemKeyTags is set.
+type SecDNS10Update struct {
+	Urgent     bool
+	Add        []SecDNS10DSData
+	Chg        []SecDNS10DSData
+	RemKeyTags []uint16
+}
+
+// ParseSecDNSCreate parses the secDNS create extension of a domain create
+// command. A secDNS-1.0 extension is normalised to the secDNS-1.1 model. If the
+// command has no secDNS extension nil is returned.
+func ParseSecDNSCreate(doc *etree.Document) (*SecDNSCreate, error) {
+	if el := commandExtensionElement(doc, "create", NamespaceIETFSecDNS11.String()); el != nil {
+		return parseSecDNSCreate(el)
+	}
+
+	create, err := ParseSecDNS10Create(doc)
+	if err != nil || create == nil {
+		return nil, err
+	}
+
+	return create.Normalize(), nil
+}
+
+// ParseSecDNSUpdate parses the secDNS update extension of a domain update
+// command. A secDNS-1.0 extension is normalised to the secDNS-1.1 model. If the
+// command has no secDNS extension nil is returned.
+func ParseSecDNSUpdate(doc *etree.Document) (*SecDNSUpdate, error) {
+	if el := commandExtensionElement(doc, "update", NamespaceIETFSecDNS11.String()); el != nil {
+		return parseSecDNSUpdate(el)
+	}
+
+	update, err := ParseSecDNS10Update(doc)
+	if err != nil || update == nil {
+		return nil, err
+	}
+
+	return update.Normalize(), nil
+}
+
+// ParseSecDNS10Create parses the secDNS-1.0 create extension of a domain
+// create command. If the command has no such extension nil is returned.
+func ParseSecDNS10Create(doc *etree.Document) (*SecDNS10Create, error) {
+	el := commandExtensionElement(doc, "create", NamespaceIETFSecDNS10.String())
+	if el == nil {
+		return nil, nil
+	}
+
+	dsData, err := parseSecDNS10DSDataList(el)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dsData) == 0 {
+		return nil, missingParameterError("dsData", NamespaceIETFSecDNS10.String())
+	}
+
+	return &SecDNS10Create{DSData: dsData}, nil
+}
+
+// ParseSecDNS10Update parses the secDNS-1.0 update extension of a domain
+// update command. If the command has no such extension nil is returned.
+func ParseSecDNS10Update(doc *etree.Document) (*SecDNS10Update, error) {
+	ns := NamespaceIETFSecDNS10.String()
+
+	el := commandExtensionElement(doc, "update", ns)
+	if el == nil {
+		return nil, nil
+	}
+
+	urgent, err := parseSecDNSUrgent(el, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &SecDNS10Update{Urgent: urgent}
+
+	switch {
+	case childElement(el, "add", ns) != nil:
+		update.Add, err = parseSecDNS10DSDataList(childElement(el, "add", ns))
+	case childElement(el, "chg", ns) != nil:
+		update.Chg, err = parseSecDNS10DSDataList(childElement(el, "chg", ns))
+	case childElement(el, "rem", ns) != nil:
+		for _, text := range childTexts(childElement(el, "rem", ns), "keyTag", ns) {
+			keyTag, err := parseSecDNSUint(text, "keyTag", ns, math.MaxUint16)
+			if err != nil {
+				return nil, err
+			}
+
+			update.RemKeyTags = append(update.RemKeyTags, uint16(keyTag))
+		}
+	default:
+		return nil, missingParameterError("add", ns)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// Normalize converts the secDNS-1.0 create to the secDNS-1.1 model. Since
+// secDNS-1.1 has a single maxSigLife the lowest one from the dsData is used.
+func (c *SecDNS10Create) Normalize() *SecDNSCreate {
+	maxSigLife, dsData := normalizeSecDNS10DSData(c.DSData)
+
+	return &SecDNSCreate{
+		MaxSigLife: maxSigLife,
+		DSData:     dsData,
+	}
+}
+
+// Normalize converts the secDNS-1.0 update to the secDNS-1.1 model. A chg
+// replaces all DS data and is converted to a rem all and an add, the removed
+// key tags are converted to dsData with only the key tag set.
+func (u *SecDNS10Update) Normalize() *SecDNSUpdate {
+	update := &SecDNSUpdate{
+		Urgent: u.Urgent,
+	}
+
+	for _, keyTag := range u.RemKeyTags {
+		update.RemDSData = append(update.RemDSData, SecDNSDSData{KeyTag: keyTag})
+	}
+
+	if len(u.Add) > 0 {
+		update.MaxSigLife, update.AddDSData = normalizeSecDNS10DSData(u.Add)
+	}
+
+	if len(u.Chg) > 0 {
+		update.RemAll = true
+		update.MaxSigLife, update.AddDSData = normalizeSecDNS10DSData(u.Chg)
+	}
+
+	return update
+}
+
+func normalizeSecDNS10DSData(list []SecDNS10DSData) (int, []SecDNSDSData) {
+	var (
+		maxSigLife int
+		dsData     = make([]SecDNSDSData, 0, len(list))
+	)
+
+	for _, ds := range list {
+		if ds.MaxSigLife > 0 && (maxSigLife == 0 || ds.MaxSigLife < maxSigLife) {
+			maxSigLife = ds.MaxSigLife
+		}
+
+		dsData = append(dsData, SecDNSDSData{
+			KeyTag:     ds.KeyTag,
+			Alg:        ds.Alg,
+			DigestType: ds.DigestType,
+			Digest:     ds.Digest,
+			KeyData:    ds.KeyData,
+		})
+	}
+
+	return maxSigLife, dsData
+}
+
+func parseSecDNSCreate(el *etree.Element) (*SecDNSCreate, error) {
+	ns := NamespaceIETFSecDNS11.String()
+	create := &SecDNSCreate{}
+
+	var err error
+
+	if create.MaxSigLife, err = parseSecDNSMaxSigLife(el, ns); err != nil {
+		return nil, err
+	}
+
+	if create.DSData, create.KeyData, err = parseSecDNSDataList(el); err != nil {
+		return nil, err
+	}
+
+	if len(create.DSData) == 0 && len(create.KeyData) == 0 {
+		return nil, missingParameterError("dsData", ns)
+	}
+
+	return create, nil
+}
+
+func parseSecDNSUpdate(el *etree.Element) (*SecDNSUpdate, error) {
+	ns := NamespaceIETFSecDNS11.String()
+
+	urgent, err := parseSecDNSUrgent(el, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &SecDNSUpdate{Urgent: urgent}
+
+	if rem := childElement(el, "rem", ns); rem != nil {
+		if all := childElement(rem, "all", ns); all != nil {
+			if update.RemAll, err = ParseXMLBool(all.Text()); err != nil {
+				return nil, syntaxError("all", ns, strings.TrimSpace(all.Text()))
+			}
+		} else if update.RemDSData, update.RemKeyData, err = parseSecDNSDataList(rem); err != nil {
+			return nil, err
+		}
+	}
+
+	if add := childElement(el, "add", ns); add != nil {
+		if update.AddDSData, update.AddKeyData, err = parseSecDNSDataList(add); err != nil {
+			return nil, err
+		}
+	}
+
+	if chg := childElement(el, "chg", ns); chg != nil {
+		if update.MaxSigLife, err = parseSecDNSMaxSigLife(chg, ns); err != nil {
+			return nil, err
+		}
+	}
+
+	return update, nil
+}
+
+// parseSecDNSDataList parses the secDNS-1.1 dsData or keyData children of el.
+// Using both is not allowed.
+func parseSecDNSDataList(el *etree.Element) ([]SecDNSDSData, []SecDNSKeyData, error) {
+	var (
+		ns      = NamespaceIETFSecDNS11.String()
+		dsData  []SecDNSDSData
+		keyData []SecDNSKeyData
+	)
+
+	for _, child := range childElements(el, "dsData", ns) {
+		ds, err := parseSecDNSDSData(child, ns)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dsData = append(dsData, SecDNSDSData{
+			KeyTag:     ds.KeyTag,
+			Alg:        ds.Alg,
+			DigestType: ds.DigestType,
+			Digest:     ds.Digest,
+			KeyData:    ds.KeyData,
+		})
+	}
+
+	for _, child := range childElements(el, "keyData", ns) {
+		key, err := parseSecDNSKeyData(child, ns)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keyData = append(keyData, *key)
+	}
+
+	if len(dsData) > 0 && len(keyData) > 0 {
+		return nil, nil, NewError(StatusParameterPolicyError).WithValues(Value{
+			Element:   "keyData",
+			Namespace: ns,
+		})
+	}
+
+	return dsData, keyData, nil
+}
+
+func parseSecDNS10DSDataList(el *etree.Element) ([]SecDNS10DSData, error) {
+	var dsData []SecDNS10DSData
+
+	for _, child := range childElements(el, "dsData", NamespaceIETFSecDNS10.String()) {
+		ds, err := parseSecDNSDSData(child, NamespaceIETFSecDNS10.String())
+		if err != nil {
+			return nil, err
+		}
+
+		dsData = append(dsData, *ds)
+	}
+
+	return dsData, nil
+}
+
+// parseSecDNSDSData parses a dsData element in either namespace. The
+// maxSigLife is only allowed in secDNS-1.0.
+func parseSecDNSDSData(el *etree.Element, ns string) (*SecDNS10DSData, error) {
+	keyTag, err := parseSecDNSRequiredUint(el, "keyTag", ns, math.MaxUint16)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, err := parseSecDNSRequiredUint(el, "alg", ns, math.MaxUint8)
+	if err != nil {
+		return nil, err
+	}
+
+	digestType, err := parseSecDNSRequiredUint(el, "digestType", ns, math.MaxUint8)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := &SecDNS10DSData{
+		KeyTag:     uint16(keyTag),
+		Alg:        uint8(alg),
+		DigestType: uint8(digestType),
+	}
+
+	if ds.Digest, err = requiredChildText(el, "digest", ns); err != nil {
+		return nil, err
+	}
+
+	if _, err := hex.DecodeString(ds.Digest); err != nil {
+		return nil, syntaxError("digest", ns, ds.Digest)
+	}
+
+	if ns == NamespaceIETFSecDNS10.String() {
+		if ds.MaxSigLife, err = parseSecDNSMaxSigLife(el, ns); err != nil {
+			return nil, err
+		}
+	}
+
+	if keyData := childElement(el, "keyData", ns); keyData != nil {
+		if ds.KeyData, err = parseSecDNSKeyData(keyData, ns); err != nil {
+			return nil, err
+		}
+	}
+
+	return ds, nil
+}
+
+func parseSecDNSKeyData(el *etree.Element, ns string) (*SecDNSKeyData, error) {
+	flags, err := parseSecDNSRequiredUint(el, "flags", ns, math.MaxUint16)
+	if err != nil {
+		return nil, err
+	}
+
+	protocol, err := parseSecDNSRequiredUint(el, "protocol", ns, math.MaxUint8)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, err := parseSecDNSRequiredUint(el, "alg", ns, math.MaxUint8)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := requiredChildText(el, "pubKey", ns)
+	if err != nil {
+		return nil, err
+	}
+
+	// base64Binary allows whitespace, e.g. line breaks in long keys.
+	pubKey = strings.Join(strings.Fields(pubKey), "")
+
+	if _, err := base64.StdEncoding.DecodeString(pubKey); err != nil {
+		return nil, syntaxError("pubKey", ns, pubKey)
+	}
+
+	return &SecDNSKeyData{
+		Flags:    uint16(flags),
+		Protocol: uint8(protocol),
+		Alg:      uint8(alg),
+		PubKey:   pubKey,
+	}, nil
+}
+
+func parseSecDNSRequiredUint(el *etree.Element, tag, ns string, maxValue uint64) (uint64, error) {
+	text, err := requiredChildText(el, tag, ns)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseSecDNSUint(text, tag, ns, maxValue)
+}
+
+func parseSecDNSUint(text, tag, ns string, maxValue uint64) (uint64, error) {
+	value, err := strconv.ParseUint(text, 10, 64)
+	if err != nil {
+		return 0, syntaxError(tag, ns, text)
+	}
+
+	if value > maxValue {
+		return 0, rangeError(tag, ns, text)
+	}
+
+	return value, nil
+}
+
+// parseSecDNSMaxSigLife parses the optional maxSigLife child of el, 0 is
+// returned if it is missing.
+func parseSecDNSMaxSigLife(el *etree.Element, ns string) (int, error) {
+	text := childText(el, "maxSigLife", ns)
+	if text == "" {
+		return 0, nil
+	}
+
+	value, err := parseSecDNSUint(text, "maxSigLife", ns, math.MaxInt32)
+	if err != nil {
+		return 0, err
+	}
+
+	if value < 1 {
+		return 0, rangeError("maxSigLife", ns, text)
+	}
+
+	return int(value), nil
+}
+
+func parseSecDNSUrgent(el *etree.Element, ns string) (bool, error) {
+	value := el.SelectAttrValue("urgent", "false")
+
+	urgent, err := ParseXMLBool(value)
+	if err != nil {
+		return false, syntaxError("update", ns, value)
+	}
+
+	return urgent, nil
+}
+
+// Element returns the secDNS-1.1 create element.
+func (c *SecDNSCreate) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFSecDNS11.String(), "create")
+
+	createSecDNSMaxSigLifeChild(el, c.MaxSigLife)
+	createSecDNSDataChildren(el, c.DSData, c.KeyData)
+
+	return el
+}
+
+// Element returns the secDNS-1.1 update element.
+func (u *SecDNSUpdate) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFSecDNS11.String(), "update")
+
+	if u.Urgent {
+		el.CreateAttr("urgent", FormatXMLBool(u.Urgent))
+	}
+
+	if u.RemAll {
+		createTextChild(createChild(el, "rem"), "all", FormatXMLBool(true))
+	} else if len(u.RemDSData) > 0 || len(u.RemKeyData) > 0 {
+		createSecDNSDataChildren(createChild(el, "rem"), u.RemDSData, u.RemKeyData)
+	}
+
+	if len(u.AddDSData) > 0 || len(u.AddKeyData) > 0 {
+		createSecDNSDataChildren(createChild(el, "add"), u.AddDSData, u.AddKeyData)
+	}
+
+	if u.MaxSigLife > 0 {
+		createSecDNSMaxSigLifeChild(createChild(el, "chg"), u.MaxSigLife)
+	}
+
+	return el
+}
+
+// SecDNSInfData represent the infData element of a domain info response
+// extension.
+type SecDNSInfData struct {
+	MaxSigLife int
+	DSData     []SecDNSDSData
+	KeyData    []SecDNSKeyData
+}
+
+// Element returns the secDNS-1.1 infData element.
+func (d *SecDNSInfData) Element() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFSecDNS11.String(), "infData")
+
+	createSecDNSMaxSigLifeChild(el, d.MaxSigLife)
+	createSecDNSDataChildren(el, d.DSData, d.KeyData)
+
+	return el
+}
+
+// Element10 returns the secDNS-1.0 infData element. Since secDNS-1.0 only
+// supports the DS data interface KeyData is ignored and MaxSigLife is set on
+// every dsData.
+func (d *SecDNSInfData) Element10() *etree.Element {
+	el := newPrefixedElement(NamespaceIETFSecDNS10.String(), "infData")
+
+	for _, ds := range d.DSData {
+		child := createChild(el, "dsData")
+
+		createSecDNSDSDataFields(child, ds)
+		createSecDNSMaxSigLifeChild(child, d.MaxSigLife)
+
+		if ds.KeyData != nil {
+			createSecDNSKeyDataChild(child, *ds.KeyData)
+		}
+	}
+
+	return el
+}
+
+// ElementForSession returns the infData element in the secDNS version chosen
+// at login by the session. The secDNS-1.1 element is returned if the session
+// is nil or the client didn't choose secDNS-1.0.
+func (d *SecDNSInfData) ElementForSession(session *Session) *etree.Element {
+	if session != nil {
+		if ns, ok := session.NegotiatedNamespace(NamespaceIETFSecDNS11); ok && ns == NamespaceIETFSecDNS10 {
+			return d.Element10()
+		}
+	}
+
+	return d.Element()
+}
+
+func createSecDNSDataChildren(el *etree.Element, dsData []SecDNSDSData, keyData []SecDNSKeyData) {
+	for _, ds := range dsData {
+		child := createChild(el, "dsData")
+
+		createSecDNSDSDataFields(child, ds)
+
+		if ds.KeyData != nil {
+			createSecDNSKeyDataChild(child, *ds.KeyData)
+		}
+	}
+
+	for _, key := range keyData {
+		createSecDNSKeyDataChild(el, key)
+	}
+}
+
+func createSecDNSDSDataFields(el *etree.Element, ds SecDNSDSData) {
+	createTextChild(el, "keyTag", strconv.FormatUint(uint64(ds.KeyTag), 10))
+	createTextChild(el, "alg", strconv.FormatUint(uint64(ds.Alg), 10))
+	createTextChild(el, "digestType", strconv.FormatUint(uint64(ds.DigestType), 10))
+	createTextChild(el, "digest", ds.Digest)
+}
+
+func createSecDNSKeyDataChild(el *etree.Element, key SecDNSKeyData) {
+	child := createChild(el, "keyData")
+
+	createTextChild(child, "flags", strconv.FormatUint(uint64(key.Flags), 10))
+	createTextChild(child, "protocol", strconv.FormatUint(uint64(key.Protocol), 10))
+	createTextChild(child, "alg", strconv.FormatUint(uint64(key.Alg), 10))
+	createTextChild(child, "pubKey", key.PubKey)
+}
+
+func createSecDNSMaxSigLifeChild(el *etree.Element, maxSigLife int) {
+	if maxSigLife > 0 {
+		createTextChild(el, "maxSigLife", strconv.Itoa(maxSigLife))
+	}
+}
@@ -0,0 +1,311 @@
+package epplib
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	secDNSDomainCreate = `<domain:create xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:create>`
+	secDNSDomainUpdate = `<domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:update>`
+)
+
+func TestParseSecDNSCreate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		extension string
+		expected  *SecDNSCreate
+	}{
+		{
+			name: "no extension",
+		},
+		{
+			name: "secDNS-1.1 dsData",
+			extension: `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1">
+  <secDNS:maxSigLife>604800</secDNS:maxSigLife>
+  <secDNS:dsData>
+    <secDNS:keyTag>12345</secDNS:keyTag>
+    <secDNS:alg>3</secDNS:alg>
+    <secDNS:digestType>1</secDNS:digestType>
+    <secDNS:digest>49FD46E6C4B45C55D4AC</secDNS:digest>
+    <secDNS:keyData>
+      <secDNS:flags>257</secDNS:flags>
+      <secDNS:protocol>3</secDNS:protocol>
+      <secDNS:alg>1</secDNS:alg>
+      <secDNS:pubKey>AQPJ////4Q==</secDNS:pubKey>
+    </secDNS:keyData>
+  </secDNS:dsData>
+</secDNS:create>`,
+			expected: &SecDNSCreate{
+				MaxSigLife: 604800,
+				DSData: []SecDNSDSData{
+					{
+						KeyTag:     12345,
+						Alg:        3,
+						DigestType: 1,
+						Digest:     "49FD46E6C4B45C55D4AC",
+						KeyData: &SecDNSKeyData{
+							Flags:    257,
+							Protocol: 3,
+							Alg:      1,
+							PubKey:   "AQPJ////4Q==",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "secDNS-1.1 keyData",
+			extension: `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1">
+  <secDNS:keyData>
+    <secDNS:flags>257</secDNS:flags>
+    <secDNS:protocol>3</secDNS:protocol>
+    <secDNS:alg>1</secDNS:alg>
+    <secDNS:pubKey>AQPJ////4Q==</secDNS:pubKey>
+  </secDNS:keyData>
+</secDNS:create>`,
+			expected: &SecDNSCreate{
+				KeyData: []SecDNSKeyData{{Flags: 257, Protocol: 3, Alg: 1, PubKey: "AQPJ////4Q=="}},
+			},
+		},
+		{
+			name: "pubKey with line breaks",
+			extension: `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1">
+  <secDNS:keyData>
+    <secDNS:flags>257</secDNS:flags>
+    <secDNS:protocol>3</secDNS:protocol>
+    <secDNS:alg>1</secDNS:alg>
+    <secDNS:pubKey>
+      AQPJ////
+      4Q==
+    </secDNS:pubKey>
+  </secDNS:keyData>
+</secDNS:create>`,
+			expected: &SecDNSCreate{
+				KeyData: []SecDNSKeyData{{Flags: 257, Protocol: 3, Alg: 1, PubKey: "AQPJ////4Q=="}},
+			},
+		},
+		{
+			name: "secDNS-1.0 is normalised",
+			extension: `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.0">
+  <secDNS:dsData>
+    <secDNS:keyTag>12345</secDNS:keyTag>
+    <secDNS:alg>3</secDNS:alg>
+    <secDNS:digestType>1</secDNS:digestType>
+    <secDNS:digest>49FD46E6C4B45C55D4AC</secDNS:digest>
+    <secDNS:maxSigLife>604800</secDNS:maxSigLife>
+  </secDNS:dsData>
+  <secDNS:dsData>
+    <secDNS:keyTag>12346</secDNS:keyTag>
+    <secDNS:alg>3</secDNS:alg>
+    <secDNS:digestType>1</secDNS:digestType>
+    <secDNS:digest>49FD46E6C4B45C55D4AD</secDNS:digest>
+    <secDNS:maxSigLife>3600</secDNS:maxSigLife>
+  </secDNS:dsData>
+</secDNS:create>`,
+			expected: &SecDNSCreate{
+				MaxSigLife: 3600,
+				DSData: []SecDNSDSData{
+					{KeyTag: 12345, Alg: 3, DigestType: 1, Digest: "49FD46E6C4B45C55D4AC"},
+					{KeyTag: 12346, Alg: 3, DigestType: 1, Digest: "49FD46E6C4B45C55D4AD"},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			create, err := ParseSecDNSCreate(commandDocument(t, "create", secDNSDomainCreate, tc.extension))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, create)
+		})
+	}
+}
+
+func TestParseSecDNSUpdate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		extension string
+		expected  *SecDNSUpdate
+	}{
+		{
+			name: "secDNS-1.1 rem all and add",
+			extension: `<secDNS:update xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1" urgent="true">
+  <secDNS:rem>
+    <secDNS:all>true</secDNS:all>
+  </secDNS:rem>
+  <secDNS:add>
+    <secDNS:dsData>
+      <secDNS:keyTag>12346</secDNS:keyTag>
+      <secDNS:alg>3</secDNS:alg>
+      <secDNS:digestType>1</secDNS:digestType>
+      <secDNS:digest>38EC35D5B3A34B44C39B</secDNS:digest>
+    </secDNS:dsData>
+  </secDNS:add>
+  <secDNS:chg>
+    <secDNS:maxSigLife>605900</secDNS:maxSigLife>
+  </secDNS:chg>
+</secDNS:update>`,
+			expected: &SecDNSUpdate{
+				Urgent:     true,
+				RemAll:     true,
+				AddDSData:  []SecDNSDSData{{KeyTag: 12346, Alg: 3, DigestType: 1, Digest: "38EC35D5B3A34B44C39B"}},
+				MaxSigLife: 605900,
+			},
+		},
+		{
+			name: "secDNS-1.0 rem is normalised",
+			extension: `<secDNS:update xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.0">
+  <secDNS:rem>
+    <secDNS:keyTag>12345</secDNS:keyTag>
+    <secDNS:keyTag>12346</secDNS:keyTag>
+  </secDNS:rem>
+</secDNS:update>`,
+			expected: &SecDNSUpdate{
+				RemDSData: []SecDNSDSData{{KeyTag: 12345}, {KeyTag: 12346}},
+			},
+		},
+		{
+			name: "secDNS-1.0 chg is normalised",
+			extension: `<secDNS:update xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.0" urgent="1">
+  <secDNS:chg>
+    <secDNS:dsData>
+      <secDNS:keyTag>12346</secDNS:keyTag>
+      <secDNS:alg>3</secDNS:alg>
+      <secDNS:digestType>1</secDNS:digestType>
+      <secDNS:digest>38EC35D5B3A34B44C39B</secDNS:digest>
+      <secDNS:maxSigLife>3600</secDNS:maxSigLife>
+    </secDNS:dsData>
+  </secDNS:chg>
+</secDNS:update>`,
+			expected: &SecDNSUpdate{
+				Urgent:     true,
+				RemAll:     true,
+				AddDSData:  []SecDNSDSData{{KeyTag: 12346, Alg: 3, DigestType: 1, Digest: "38EC35D5B3A34B44C39B"}},
+				MaxSigLife: 3600,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			update, err := ParseSecDNSUpdate(commandDocument(t, "update", secDNSDomainUpdate, tc.extension))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, update)
+		})
+	}
+}
+
+func TestParseSecDNSErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name         string
+		extension    string
+		expectedCode int
+		expectedElem string
+	}{
+		{
+			name:         "key tag out of range",
+			extension:    `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1"><secDNS:dsData><secDNS:keyTag>65536</secDNS:keyTag><secDNS:alg>3</secDNS:alg><secDNS:digestType>1</secDNS:digestType><secDNS:digest>AB</secDNS:digest></secDNS:dsData></secDNS:create>`,
+			expectedCode: StatusValueRangeError,
+			expectedElem: "keyTag",
+		},
+		{
+			name:         "digest not hex",
+			extension:    `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1"><secDNS:dsData><secDNS:keyTag>1</secDNS:keyTag><secDNS:alg>3</secDNS:alg><secDNS:digestType>1</secDNS:digestType><secDNS:digest>XY</secDNS:digest></secDNS:dsData></secDNS:create>`,
+			expectedCode: StatusValueSyntaxError,
+			expectedElem: "digest",
+		},
+		{
+			name:         "missing alg",
+			extension:    `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1"><secDNS:keyData><secDNS:flags>257</secDNS:flags><secDNS:protocol>3</secDNS:protocol><secDNS:pubKey>AQ==</secDNS:pubKey></secDNS:keyData></secDNS:create>`,
+			expectedCode: StatusMissingParameter,
+			expectedElem: "alg",
+		},
+		{
+			name:         "maxSigLife zero",
+			extension:    `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1"><secDNS:maxSigLife>0</secDNS:maxSigLife><secDNS:keyData><secDNS:flags>257</secDNS:flags><secDNS:protocol>3</secDNS:protocol><secDNS:alg>1</secDNS:alg><secDNS:pubKey>AQ==</secDNS:pubKey></secDNS:keyData></secDNS:create>`,
+			expectedCode: StatusValueRangeError,
+			expectedElem: "maxSigLife",
+		},
+		{
+			name:         "both dsData and keyData",
+			extension:    `<secDNS:create xmlns:secDNS="urn:ietf:params:xml:ns:secDNS-1.1"><secDNS:dsData><secDNS:keyTag>1</secDNS:keyTag><secDNS:alg>3</secDNS:alg><secDNS:digestType>1</secDNS:digestType><secDNS:digest>AB</secDNS:digest></secDNS:dsData><secDNS:keyData><secDNS:flags>257</secDNS:flags><secDNS:protocol>3</secDNS:protocol><secDNS:alg>1</secDNS:alg><secDNS:pubKey>AQ==</secDNS:pubKey></secDNS:keyData></secDNS:create>`,
+			expectedCode: StatusParameterPolicyError,
+			expectedElem: "keyData",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseSecDNSCreate(commandDocument(t, "create", secDNSDomainCreate, tc.extension))
+
+			var eppErr *EppError
+
+			require.True(t, errors.As(err, &eppErr))
+			assert.Equal(t, tc.expectedCode, eppErr.Code)
+			require.Len(t, eppErr.Values, 1)
+			assert.Equal(t, tc.expectedElem, eppErr.Values[0].Element)
+		})
+	}
+}
+
+func TestSecDNSInfData(t *testing.T) {
+	t.Parallel()
+
+	data := &SecDNSInfData{
+		MaxSigLife: 604800,
+		DSData: []SecDNSDSData{
+			{KeyTag: 12345, Alg: 3, DigestType: 1, Digest: "49FD46E6C4B45C55D4AC"},
+		},
+	}
+
+	el := data.Element()
+	assert.Equal(t, NamespaceIETFSecDNS11.String(), el.SelectAttrValue("xmlns:secDNS", ""))
+	assert.Equal(t, "604800", el.SelectElement("maxSigLife").Text())
+	assert.Equal(t, "12345", el.FindElement("dsData/keyTag").Text())
+	assert.Nil(t, el.FindElement("dsData/maxSigLife"))
+
+	el = data.Element10()
+	assert.Equal(t, NamespaceIETFSecDNS10.String(), el.SelectAttrValue("xmlns:secDNS", ""))
+	assert.Nil(t, el.SelectElement("maxSigLife"))
+	assert.Equal(t, "604800", el.FindElement("dsData/maxSigLife").Text())
+	assert.Equal(t, "49FD46E6C4B45C55D4AC", el.FindElement("dsData/digest").Text())
+
+	assert.Equal(t, NamespaceIETFSecDNS11.String(), data.ElementForSession(nil).SelectAttrValue("xmlns:secDNS", ""))
+
+	session := &Session{}
+	require.NoError(t, session.Login("ClientX", "en", nil, Namespaces{NamespaceIETFSecDNS10}))
+	assert.Equal(t, NamespaceIETFSecDNS10.String(), data.ElementForSession(session).SelectAttrValue("xmlns:secDNS", ""))
+
+	session = &Session{}
+	require.NoError(t, session.Login("ClientX", "en", nil, Namespaces{NamespaceIETFSecDNS11}))
+	assert.Equal(t, NamespaceIETFSecDNS11.String(), data.ElementForSession(session).SelectAttrValue("xmlns:secDNS", ""))
+}
+
+func TestSecDNSUpdateRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	update := &SecDNSUpdate{
+		Urgent:     true,
+		RemKeyData: []SecDNSKeyData{{Flags: 257, Protocol: 3, Alg: 1, PubKey: "AQ=="}},
+		AddKeyData: []SecDNSKeyData{{Flags: 257, Protocol: 3, Alg: 8, PubKey: "AwE="}},
+		MaxSigLife: 3600,
+	}
+
+	extension, err := etree.NewDocumentWithRoot(update.Element()).WriteToString()
+	require.NoError(t, err)
+
+	got, err := ParseSecDNSUpdate(commandDocument(t, "update", secDNSDomainUpdate, extension))
+	require.NoError(t, err)
+	assert.Equal(t, update, got)
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"net"
 	"os"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -53,6 +54,60 @@ type Server struct {
 	// is bigger than the set size in bytes. 0 indicates no limit.
 	MaxMessageSize uint32
 
+	// KeepAlivePeriod is the TCP keep-alive period for accepted connections.
+	// 0 indicates one minute and a negative value disables keep-alive.
+	KeepAlivePeriod time.Duration
+
+	// KeepAliveConfig if set configures TCP keep-alive, with the idle time,
+	// interval and count, for accepted connections and takes precedence over
+	// KeepAlivePeriod.
+	KeepAliveConfig *net.KeepAliveConfig
+
+	// ProxyProtocol if set reads a PROXY protocol header from connections
+	// from trusted proxies before the TLS handshake. The client address from
+	// the header is used as the remote address of the connection.
+	ProxyProtocol *ProxyProtocol
+
+	// AcceptPolicy if set decides if an accepted connection should be served
+	// before the TLS handshake is started. Rejected connections are closed.
+	// For connections from trusted proxies it is applied after the PROXY
+	// protocol header has been read.
+	AcceptPolicy AcceptPolicy
+
+	// MaxPendingHandshakes is the maximum number of concurrent TLS handshakes.
+	// Connections accepted while the limit is reached are closed. 0 indicates
+	// no limit.
+	MaxPendingHandshakes int
+
+	// MaxConnections is the maximum number of concurrent connections. A
+	// connection over the limit gets a 2502 response instead of the greeting
+	// and is closed. 0 indicates no limit.
+	MaxConnections int
+
+	// SessionLimit returns the maximum number of concurrent logged in
+	// sessions for a client id, e.g. from the registrar configuration. A
+	// login that would exceed the limit is refused by Session.Login with
+	// ErrSessionLimitExceeded before the session is logged in, the
+	// CommandMux replies 2502 and closes the connection. 0 indicates no
+	// limit.
+	SessionLimit func(ctx context.Context, clientID string) int
+
+	// Goodbye is called by Shutdown for every idle connection before it is
+	// closed and can write a final message on rw, e.g. a 1500 or 2500
+	// response.
+	Goodbye func(ctx context.Context, rw *ResponseWriter)
+
+	// PanicHook is called with the recovered value and stack trace when a
+	// panic is recovered while serving a connection, including panics in
+	// handlers bound to a CommandMux, e.g. to report it to an error tracker.
+	// Panics are always logged on Logger.
+	PanicHook func(ctx context.Context, recovered any, stack []byte)
+
+	// CloseOnPanic makes the server reply 2500 and close the connection when
+	// HandleCommand panics. By default 2400 is replied and the connection is
+	// kept open.
+	CloseOnPanic bool
+
 	// Logger logs errors when accepting connections, unexpected behavior
 	// from handlers and underlying connection errors.
 	Logger *slog.Logger
@@ -63,6 +118,17 @@ type Server struct {
 	// mu guards activeConn.
 	mu sync.Mutex
 
+	// inShutdown is set when Shutdown has been called. It is set while
+	// holding mu.
+	inShutdown atomic.Bool
+
+	// closed is set when Serve returns. It is guarded by mu.
+	closed bool
+
+	// pendingHandshakes counts the connections that have been accepted but
+	// not completed the TLS handshake when MaxPendingHandshakes is set.
+	pendingHandshakes atomic.Int64
+
 	// counts active connections, in created on new connections and decreased
 	// when connections are closed.
 	wg sync.WaitGroup
@@ -86,11 +152,16 @@ func (s *Server) Serve(listener Listener) error {
 	s.listener = listener
 	s.listenerMu.Unlock()
 
+	s.mu.Lock()
 	s.activeConn = make(map[*eppConn]struct{})
+	s.closed = false
+	s.mu.Unlock()
 
 	defer func() {
 		s.mu.Lock()
 
+		s.closed = true
+
 		for c := range s.activeConn {
 			_ = c.stopAwaitMessage()
 		}
@@ -125,25 +196,147 @@ func (s *Server) Serve(listener Listener) error {
 			return err
 		}
 
-		keepAliveConn, ok := conn.(KeepAliveConn)
-		if !ok {
-			return errors.New("connection does not support keep alive")
-		}
+		// The wait group is only added to while holding mu and before
+		// Shutdown has been called so that it doesn't race with the Wait in
+		// Shutdown.
+		s.mu.Lock()
 
-		if err := keepAliveConn.SetKeepAlive(true); err != nil {
-			return err
-		}
+		if s.inShutdown.Load() {
+			s.mu.Unlock()
 
-		if err := keepAliveConn.SetKeepAlivePeriod(time.Minute); err != nil {
-			return err
+			_ = conn.Close()
+
+			continue
 		}
 
 		s.wg.Add(1)
+		s.mu.Unlock()
+
+		if !s.acceptConn(conn) {
+			s.wg.Done()
+			continue
+		}
+
+		s.setKeepAlive(conn)
 
 		go s.serveConn(conn)
 	}
 }
 
+// setKeepAlive configures TCP keep-alive for connections that support it.
+// Keep-alive is best-effort so failures are logged and the connection is
+// served anyway.
+func (s *Server) setKeepAlive(conn net.Conn) {
+	var err error
+
+	if configConn, ok := conn.(KeepAliveConfigConn); ok && s.KeepAliveConfig != nil {
+		err = configConn.SetKeepAliveConfig(*s.KeepAliveConfig)
+	} else if keepAliveConn, ok := conn.(KeepAliveConn); ok {
+		err = s.setKeepAlivePeriod(keepAliveConn)
+	}
+
+	if err != nil {
+		s.Logger.Warn("could not set keep alive",
+			slog.Any("error", err),
+			slog.String("remote_addr", conn.RemoteAddr().String()),
+		)
+	}
+}
+
+// setKeepAlivePeriod enables keep-alive with KeepAlivePeriod or disables it if
+// the period is negative.
+func (s *Server) setKeepAlivePeriod(conn KeepAliveConn) error {
+	if s.KeepAlivePeriod < 0 {
+		return conn.SetKeepAlive(false)
+	}
+
+	if err := conn.SetKeepAlive(true); err != nil {
+		return err
+	}
+
+	period := s.KeepAlivePeriod
+	if period == 0 {
+		period = time.Minute
+	}
+
+	return conn.SetKeepAlivePeriod(period)
+}
+
+// acceptConn applies the AcceptPolicy and MaxPendingHandshakes to an accepted
+// connection and closes it if it is rejected. It reports whether the
+// connection should be served.
+func (s *Server) acceptConn(conn net.Conn) bool {
+	if !s.isProxied(conn) && !s.applyAcceptPolicy(conn) {
+		_ = conn.Close()
+		return false
+	}
+
+	if s.MaxPendingHandshakes > 0 {
+		if s.pendingHandshakes.Add(1) > int64(s.MaxPendingHandshakes) {
+			s.pendingHandshakes.Add(-1)
+
+			s.Logger.Info("connection rejected, too many pending handshakes",
+				slog.String("remote_addr", conn.RemoteAddr().String()),
+			)
+
+			_ = conn.Close()
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyAcceptPolicy reports whether the AcceptPolicy accepts the connection.
+func (s *Server) applyAcceptPolicy(conn net.Conn) bool {
+	if s.AcceptPolicy == nil {
+		return true
+	}
+
+	if err := s.AcceptPolicy.Accept(conn); err != nil {
+		s.Logger.Info("connection rejected",
+			slog.Any("error", err),
+			slog.String("remote_addr", conn.RemoteAddr().String()),
+		)
+
+		return false
+	}
+
+	return true
+}
+
+// isProxied reports whether a PROXY protocol header should be read from the
+// connection.
+func (s *Server) isProxied(conn net.Conn) bool {
+	return s.ProxyProtocol != nil && s.ProxyProtocol.trusted(conn.RemoteAddr())
+}
+
+// proxiedConn reads the PROXY protocol header of a connection from a trusted
+// proxy and applies the AcceptPolicy to the client address. It reports whether
+// the connection should be served.
+func (s *Server) proxiedConn(c *eppConn) (net.Conn, bool) {
+	proxied, err := c.readProxyHeader(s.ProxyProtocol, s.ProxyProtocol.headerTimeout(s.ReadTimeout))
+	if err != nil {
+		s.Logger.Info("failed to read proxy protocol header",
+			slog.Any("error", err),
+			slog.String("remote_addr", c.conn.RemoteAddr().String()),
+		)
+
+		return nil, false
+	}
+
+	return proxied, s.applyAcceptPolicy(proxied)
+}
+
+// handshakeDone decreases the number of pending handshakes counted by
+// acceptConn.
+func (s *Server) handshakeDone() {
+	if s.MaxPendingHandshakes > 0 {
+		s.pendingHandshakes.Add(-1)
+	}
+}
+
 // Close will gracefully stop the server.
 func (s *Server) Close() error {
 	s.listenerMu.RLock()
@@ -153,38 +346,117 @@ func (s *Server) Close() error {
 	return err
 }
 
-func (s *Server) serveConn(conn net.Conn) {
-	tlsConn := tls.Server(conn, s.TLSConfig.Clone())
+// Shutdown gracefully stops the server. It stops accepting new connections,
+// lets in-flight commands complete and closes idle connections, after calling
+// Goodbye if set. If ctx expires before all connections are closed the
+// remaining connections are forcibly closed and their contexts canceled.
+// The number of forcibly closed connections is returned together with the
+// context error.
+func (s *Server) Shutdown(ctx context.Context) (int, error) {
+	// Once inShutdown is set Serve doesn't serve any new connections and
+	// connections registered after the loop below see it.
+	s.mu.Lock()
+	s.inShutdown.Store(true)
+	s.mu.Unlock()
 
-	c := &eppConn{conn: tlsConn, maxMessageSize: s.MaxMessageSize}
+	s.listenerMu.RLock()
+	listener := s.listener
+	s.listenerMu.RUnlock()
+
+	var err error
+
+	if listener != nil {
+		err = listener.Close()
+		if errors.Is(err, net.ErrClosed) {
+			// The listener has already been closed by Close.
+			err = nil
+		}
+	}
 
 	s.mu.Lock()
-	s.activeConn[c] = struct{}{}
+
+	for c := range s.activeConn {
+		_ = c.stopAwaitMessage()
+	}
+
 	s.mu.Unlock()
 
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return 0, err
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.activeConn {
+		c.cancelCtx()
+		_ = c.Close()
+	}
+
+	return len(s.activeConn), ctx.Err()
+}
+
+func (s *Server) serveConn(conn net.Conn) {
 	// Set up a cancel context that is passed to handlers so that they, if needed,
 	// can be notified when the connection shuts down.
 	ctx, cancelCtx := context.WithCancel(context.Background())
 
+	// The connection is registered before anything is read from it so that
+	// Close and Shutdown can interrupt reading the PROXY protocol header.
+	c := &eppConn{conn: conn, maxMessageSize: s.MaxMessageSize, cancelCtx: cancelCtx}
+
+	s.mu.Lock()
+
+	overLimit := s.MaxConnections > 0 && len(s.activeConn) >= s.MaxConnections
+	s.activeConn[c] = struct{}{}
+
+	if s.inShutdown.Load() || s.closed {
+		// Shutdown or Close has already stopped the active connections.
+		_ = c.stopAwaitMessage()
+	}
+
+	s.mu.Unlock()
+
+	// The pending handshake counted by acceptConn is released once, either
+	// when the handshake is done or when we return before that.
+	handshakeDone := sync.OnceFunc(s.handshakeDone)
+
 	// Setup some cleanup for when the session exits.
 	defer func() {
-		_ = c.Close()
+		if recovered := recover(); recovered != nil {
+			// A panic outside of HandleCommand, e.g. in Greeting, closes
+			// the connection but shouldn't affect any other connection.
+			s.logPanic(ctx, c.conn.RemoteAddr(), recovered, debug.Stack())
+		}
 
-		// No need to remember the closeChan anymore.
-		s.mu.Lock()
-		delete(s.activeConn, c)
-		s.mu.Unlock()
+		handshakeDone()
+		s.closeConn(ctx, c)
+	}()
 
-		if s.CloseConnHook != nil {
-			s.CloseConnHook(ctx, c.conn.(*tls.Conn))
+	if s.isProxied(conn) {
+		proxied, ok := s.proxiedConn(c)
+		if !ok {
+			return
 		}
 
-		cancelCtx()
+		conn = proxied
+	}
 
-		// Countdown the wait group so that the entire listener can shut down
-		// when this reaches zero if it wants to.
-		s.wg.Done()
-	}()
+	tlsConn := tls.Server(conn, s.TLSConfig.Clone())
+
+	// c.conn is read by Close and Shutdown while holding mu.
+	s.mu.Lock()
+	c.conn = tlsConn
+	s.mu.Unlock()
 
 	err := setDeadlines(c.conn, s.ReadTimeout, s.WriteTimeout)
 	if err != nil {
@@ -196,6 +468,9 @@ func (s *Server) serveConn(conn net.Conn) {
 	}
 
 	err = tlsConn.Handshake()
+
+	handshakeDone()
+
 	if err != nil {
 		s.Logger.DebugContext(ctx, "handshake failed",
 			slog.Any("error", err),
@@ -204,6 +479,33 @@ func (s *Server) serveConn(conn net.Conn) {
 		return
 	}
 
+	// Every connection gets a session that handlers can get from the context.
+	session := newSession(tlsConn)
+
+	if s.SessionLimit != nil {
+		session.onLogin = func(clientID string) error {
+			return s.reserveSession(ctx, c, clientID)
+		}
+		session.onLogout = func(string) {
+			s.releaseSession(c)
+		}
+	}
+
+	ctx = ContextWithSession(ctx, session)
+
+	// The responseWriter can be reused for each command.
+	rw := ResponseWriter{}
+
+	if overLimit {
+		s.Logger.InfoContext(ctx, "connection limit exceeded",
+			slog.String("remote_addr", c.conn.RemoteAddr().String()),
+		)
+
+		s.connectionLimitExceeded(ctx, c, &rw)
+
+		return
+	}
+
 	if s.ConnContext != nil {
 		// This is where the user can set up any context data for the
 		// connection, for example userID's etc.
@@ -215,9 +517,6 @@ func (s *Server) serveConn(conn net.Conn) {
 		}
 	}
 
-	// The responseWriter can be reused for each command.
-	rw := ResponseWriter{}
-
 	err = setDeadlines(c.conn, s.ReadTimeout, s.WriteTimeout)
 	if err != nil {
 		s.Logger.ErrorContext(ctx, "failed to set greeting deadlines",
@@ -260,9 +559,24 @@ func (s *Server) serveConn(conn net.Conn) {
 			return
 		}
 
+		if s.inShutdown.Load() {
+			// Shutdown was called while we were handling the previous
+			// command.
+			s.goodbye(ctx, c, &rw)
+			return
+		}
+
 		// Wait for a message to appear on the connection.
 		cmd, err := c.AwaitMessage()
 		if err != nil {
+			if s.inShutdown.Load() &&
+				(errors.Is(err, net.ErrClosed) || errors.Is(err, os.ErrDeadlineExceeded)) {
+				// We were interrupted by Shutdown while waiting for a
+				// command.
+				s.goodbye(ctx, c, &rw)
+				return
+			}
+
 			if errors.Is(err, os.ErrDeadlineExceeded) {
 				// We have reached the deadline for this session, we now need
 				// to disconnect.
@@ -333,7 +647,7 @@ func (s *Server) serveConn(conn net.Conn) {
 		}
 
 		// We have some command that is waiting to be read.
-		s.HandleCommand(ctx, &rw, cmd)
+		s.handleCommand(ctx, c.conn.RemoteAddr(), &rw, cmd)
 
 		// Flush the message to the underlying connection.
 		err = rw.FlushTo(c.conn)
@@ -375,6 +689,181 @@ func (s *Server) serveConn(conn net.Conn) {
 	}
 }
 
+// closeConn closes the connection, removes it from the active connections and
+// calls the CloseConnHook if the connection got as far as the TLS handshake.
+func (s *Server) closeConn(ctx context.Context, c *eppConn) {
+	_ = c.Close()
+
+	// No need to remember the closeChan anymore.
+	s.mu.Lock()
+	delete(s.activeConn, c)
+	s.mu.Unlock()
+
+	if tlsConn, ok := c.conn.(*tls.Conn); ok && s.CloseConnHook != nil {
+		s.CloseConnHook(ctx, tlsConn)
+	}
+
+	c.cancelCtx()
+
+	// Countdown the wait group so that the entire listener can shut down
+	// when this reaches zero if it wants to.
+	s.wg.Done()
+}
+
+// connectionLimitExceeded writes a 2502 response instead of the greeting and
+// flushes it to the connection.
+func (s *Server) connectionLimitExceeded(ctx context.Context, c *eppConn, rw *ResponseWriter) {
+	rw.CloseAfterWrite()
+
+	err := WriteErrorResponse(rw, "", NewServerTransactionID(), NewError(StatusSessionLimitExceededClosingConnection))
+	if err != nil {
+		s.Logger.ErrorContext(ctx, "could not write connection limit response",
+			slog.Any("error", err),
+		)
+
+		return
+	}
+
+	err = setDeadlines(c.conn, s.ReadTimeout, s.WriteTimeout)
+	if err != nil {
+		s.Logger.ErrorContext(ctx, "failed to set connection limit deadlines",
+			slog.Any("error", err),
+		)
+
+		return
+	}
+
+	err = rw.FlushTo(c.conn)
+	if err != nil {
+		s.Logger.InfoContext(ctx, "failed to flush connection limit response",
+			slog.Any("error", err),
+		)
+	}
+}
+
+// reserveSession counts the connection as a session of the client id unless
+// that would exceed the SessionLimit, in which case ErrSessionLimitExceeded is
+// returned. It is called by Session.Login before the session is logged in.
+func (s *Server) reserveSession(ctx context.Context, c *eppConn, clientID string) error {
+	limit := s.SessionLimit(ctx, clientID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit > 0 {
+		sessions := 0
+
+		for other := range s.activeConn {
+			if other != c && other.clientID == clientID {
+				sessions++
+			}
+		}
+
+		if sessions >= limit {
+			s.Logger.InfoContext(ctx, "session limit exceeded",
+				slog.String("client_id", clientID),
+				slog.Int("limit", limit),
+				slog.String("remote_addr", c.conn.RemoteAddr().String()),
+			)
+
+			return ErrSessionLimitExceeded
+		}
+	}
+
+	c.clientID = clientID
+
+	return nil
+}
+
+// releaseSession stops counting the connection as a session when it is logged
+// out.
+func (s *Server) releaseSession(c *eppConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.clientID = ""
+}
+
+// goodbye lets Goodbye write a final message on an idle connection.
+func (s *Server) goodbye(ctx context.Context, c *eppConn, rw *ResponseWriter) {
+	if s.Goodbye == nil {
+		return
+	}
+
+	// The deadline was set to now to interrupt the wait for a command.
+	err := setDeadlines(c.conn, s.ReadTimeout, s.WriteTimeout)
+	if err != nil {
+		s.Logger.ErrorContext(ctx, "failed to set goodbye deadlines",
+			slog.Any("error", err),
+		)
+
+		return
+	}
+
+	s.Goodbye(ctx, rw)
+
+	err = rw.FlushTo(c.conn)
+	if err != nil {
+		s.Logger.InfoContext(ctx, "failed to flush goodbye",
+			slog.Any("error", err),
+		)
+	}
+}
+
+// handleCommand calls HandleCommand and replaces the response with a 2400, or
+// 2500 if CloseOnPanic is set, response if it panics.
+func (s *Server) handleCommand(ctx context.Context, remoteAddr net.Addr, rw *ResponseWriter, cmd io.Reader) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		s.logPanic(ctx, remoteAddr, recovered, debug.Stack())
+
+		// Make sure the rest of the message isn't read as the next one.
+		_, _ = io.Copy(io.Discard, cmd)
+
+		s.writePanicResponse(ctx, rw)
+	}()
+
+	s.HandleCommand(ctx, rw, cmd)
+}
+
+// writePanicResponse replaces anything written on rw with a 2400 response, or a
+// 2500 response and closes the connection if CloseOnPanic is set.
+func (s *Server) writePanicResponse(ctx context.Context, rw *ResponseWriter) {
+	result := NewError(StatusCommandFailed)
+	if s.CloseOnPanic {
+		result = NewError(StatusCommandFailedClosingConnection)
+
+		rw.CloseAfterWrite()
+	}
+
+	rw.Reset()
+
+	if err := WriteErrorResponse(rw, "", NewServerTransactionID(), result); err != nil {
+		s.Logger.ErrorContext(ctx, "could not write panic response",
+			slog.Any("error", err),
+		)
+
+		rw.Reset()
+		rw.CloseAfterWrite()
+	}
+}
+
+func (s *Server) logPanic(ctx context.Context, remoteAddr net.Addr, recovered any, stack []byte) {
+	s.Logger.ErrorContext(ctx, "panic serving connection",
+		slog.Any("panic", recovered),
+		slog.String("stack", string(stack)),
+		slog.String("remote_addr", remoteAddr.String()),
+	)
+
+	if s.PanicHook != nil {
+		s.PanicHook(ctx, recovered, stack)
+	}
+}
+
 // CloseConnection will gracefully close the provided conn.
 func (s *Server) CloseConnection(conn *tls.Conn) error {
 	s.mu.Lock()
@@ -444,6 +933,13 @@ type eppConn struct {
 	stopAwaitMsg int32
 
 	maxMessageSize uint32
+
+	// cancelCtx cancels the context passed to the handlers of the connection.
+	cancelCtx context.CancelFunc
+
+	// clientID is the client id of the logged in session counted by the
+	// SessionLimit. It is guarded by the mu of the Server.
+	clientID string
 }
 
 // AwaitMessage blocks until a message header is read from the underlying
@@ -470,6 +966,37 @@ func (c *eppConn) AwaitMessage() (io.Reader, error) {
 	return msgReader, err
 }
 
+// readProxyHeader reads the PROXY protocol header within the timeout. Like
+// AwaitMessage it is interrupted by stopAwaitMessage.
+func (c *eppConn) readProxyHeader(p *ProxyProtocol, timeout time.Duration) (net.Conn, error) {
+	// The deadline is set before we start awaiting so that it doesn't
+	// override the deadline set by stopAwaitMessage.
+	if err := c.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	atomic.StoreInt32(&c.isAwaitingMsg, 1)
+
+	if atomic.LoadInt32(&c.stopAwaitMsg) == 1 {
+		atomic.StoreInt32(&c.isAwaitingMsg, 0)
+		return nil, net.ErrClosed
+	}
+
+	conn, err := p.readHeader(c.conn)
+
+	atomic.StoreInt32(&c.isAwaitingMsg, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
 // stopAwaitMessage will unblock and close the AwaitMessage function. Future
 // calls to AwaitMessage will return net.ErrClosed.
 func (c *eppConn) stopAwaitMessage() error {
@@ -10,11 +10,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"math/big"
 	"net"
+	"net/netip"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/beevik/etree"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -84,6 +88,208 @@ func TestListenAndServeClose(t *testing.T) {
 	}
 }
 
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	var (
+		commandStarted = make(chan struct{})
+		releaseCommand = make(chan struct{})
+		commandCtxDone = make(chan struct{})
+	)
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {
+			data, _ := io.ReadAll(cmd)
+			if string(data) != "slow" {
+				_, err := fmt.Fprintf(rw, "Response to: %s", string(data))
+				assert.NoError(t, err)
+
+				return
+			}
+
+			close(commandStarted)
+
+			select {
+			case <-releaseCommand:
+			case <-ctx.Done():
+				close(commandCtxDone)
+			}
+		},
+		Goodbye: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Goodbye")
+			assert.NoError(t, err)
+		},
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+
+	served := make(chan struct{})
+
+	go func() {
+		tcpAddr, err := net.ResolveTCPAddr("tcp", ":")
+		require.NoError(t, err)
+
+		listener, err := net.ListenTCP("tcp", tcpAddr)
+		require.NoError(t, err)
+
+		err = s.Serve(listener)
+		require.NoError(t, err)
+
+		close(served)
+	}()
+
+	idleClient := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, idleClient.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, idleClient))
+
+	busyClient := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, busyClient.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, busyClient))
+
+	buf := MessageBuffer{}
+	_, err := buf.WriteString("slow")
+	require.NoError(t, err)
+	require.NoError(t, buf.FlushTo(busyClient))
+
+	<-commandStarted
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	closed, err := s.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, closed)
+
+	// The idle connection got a goodbye before it was closed.
+	assert.Equal(t, "Goodbye", getMessage(t, idleClient))
+
+	_, err = idleClient.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	// The handler of the busy connection was told to stop.
+	select {
+	case <-commandCtxDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("command context was not canceled")
+	}
+
+	select {
+	case <-served:
+	case <-time.After(10 * time.Second):
+		t.Fatal("server was not stopped")
+	}
+}
+
+func TestShutdownDrainsInFlightCommands(t *testing.T) {
+	t.Parallel()
+
+	commandStarted := make(chan struct{})
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {
+			close(commandStarted)
+			time.Sleep(100 * time.Millisecond)
+
+			_, err := fmt.Fprint(rw, "Done")
+			assert.NoError(t, err)
+		},
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+
+	go func() {
+		tcpAddr, err := net.ResolveTCPAddr("tcp", ":")
+		require.NoError(t, err)
+
+		listener, err := net.ListenTCP("tcp", tcpAddr)
+		require.NoError(t, err)
+
+		err = s.Serve(listener)
+		require.NoError(t, err)
+	}()
+
+	client := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, client.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, client))
+
+	buf := MessageBuffer{}
+	_, err := buf.WriteString("A command")
+	require.NoError(t, err)
+	require.NoError(t, buf.FlushTo(client))
+
+	<-commandStarted
+
+	responses := make(chan string, 1)
+
+	go func() {
+		responses <- getMessage(t, client)
+	}()
+
+	closed, err := s.Shutdown(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, closed)
+
+	assert.Equal(t, "Done", <-responses)
+}
+
+func TestShutdownConnectionRegisteredAfterShutdown(t *testing.T) {
+	t.Parallel()
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {},
+		Goodbye: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Goodbye")
+			assert.NoError(t, err)
+		},
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+		Logger:     slog.Default(),
+		activeConn: make(map[*eppConn]struct{}),
+	}
+
+	closed, err := s.Shutdown(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, closed)
+
+	// A connection accepted concurrently with Shutdown is registered after
+	// the active connections were stopped but still gets a goodbye.
+	server, conn := net.Pipe()
+
+	s.wg.Add(1)
+
+	go s.serveConn(server)
+
+	client := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+	defer client.Close()
+
+	require.NoError(t, client.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, client))
+	assert.Equal(t, "Goodbye", getMessage(t, client))
+
+	_, err = client.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	s.wg.Wait()
+}
+
 func TestCloseConnHookCalled(t *testing.T) {
 	t.Parallel()
 
@@ -237,6 +443,151 @@ func TestSendReceiveMessage(t *testing.T) {
 	assert.Equal(t, "Response to: A command", resp)
 }
 
+func TestSessionInContext(t *testing.T) {
+	t.Parallel()
+
+	sessions := make(chan *Session, 2)
+
+	s := Server{
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+		activeConn: make(map[*eppConn]struct{}),
+		ConnContext: func(ctx context.Context, conn *tls.Conn) (context.Context, error) {
+			sessions <- SessionFromContext(ctx)
+			return ctx, nil
+		},
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			sessions <- SessionFromContext(ctx)
+			rw.CloseAfterWrite()
+		},
+	}
+
+	clientConn, serverConn := net.Pipe()
+
+	s.wg.Add(1)
+
+	go s.serveConn(serverConn)
+
+	clientTLSConn := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, clientTLSConn.Handshake())
+
+	s.wg.Wait()
+
+	session := <-sessions
+	require.NotNil(t, session)
+	assert.Same(t, session, <-sessions)
+	assert.Equal(t, serverConn.RemoteAddr(), session.RemoteAddr())
+	assert.True(t, session.ConnectionState().HandshakeComplete)
+	assert.False(t, session.LoggedIn())
+}
+
+func TestRecoverPanic(t *testing.T) {
+	t.Parallel()
+
+	cm := &CommandMux{}
+	cm.BindCommand("info", NamespaceIETFDomain10.String(), func(_ context.Context, rw Writer, _ *etree.Document) {
+		_, _ = rw.Write([]byte("partial response"))
+
+		panic("handler failed")
+	})
+
+	for _, tc := range []struct {
+		name         string
+		mux          bool
+		closeOnPanic bool
+		expectCode   string
+		expectClosed bool
+	}{
+		{
+			name:       "reply command failed and keep connection",
+			expectCode: "2400",
+		},
+		{
+			name:       "reply command failed from command mux",
+			mux:        true,
+			expectCode: "2400",
+		},
+		{
+			name:         "reply command failed and close connection",
+			closeOnPanic: true,
+			expectCode:   "2500",
+			expectClosed: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			panics := make(chan any, 1)
+
+			s := Server{
+				TLSConfig: tls.Config{
+					InsecureSkipVerify: true,
+					Certificates:       []tls.Certificate{generateCertificate()},
+				},
+				activeConn: make(map[*eppConn]struct{}),
+				Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+				Greeting: func(ctx context.Context, rw *ResponseWriter) {
+					_, err := fmt.Fprint(rw, "Greeting")
+					assert.NoError(t, err)
+				},
+				HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {
+					panic("handler failed")
+				},
+				PanicHook: func(ctx context.Context, recovered any, stack []byte) {
+					panics <- recovered
+				},
+				CloseOnPanic: tc.closeOnPanic,
+				IdleTimeout:  10 * time.Second,
+			}
+
+			command := "A command"
+
+			if tc.mux {
+				s.HandleCommand = cm.Handle
+				command = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0"><command><info>
+<domain:info xmlns:domain="urn:ietf:params:xml:ns:domain-1.0"><domain:name>example.se</domain:name></domain:info>
+</info><clTRID>ABC-1</clTRID></command></epp>`
+			}
+
+			clientConn, serverConn := net.Pipe()
+
+			s.wg.Add(1)
+
+			go s.serveConn(serverConn)
+
+			clientTLSConn := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true})
+			require.NoError(t, clientTLSConn.Handshake())
+
+			assert.Equal(t, "Greeting", getMessage(t, clientTLSConn))
+
+			for range 2 {
+				buf := MessageBuffer{}
+				_, err := buf.WriteString(command)
+				require.NoError(t, err)
+				require.NoError(t, buf.FlushTo(clientTLSConn))
+
+				doc := etree.NewDocument()
+				require.NoError(t, doc.ReadFromString(getMessage(t, clientTLSConn)))
+				assert.Equal(t, tc.expectCode, doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+				assert.Equal(t, "handler failed", <-panics)
+
+				if tc.expectClosed {
+					break
+				}
+			}
+
+			if tc.expectClosed {
+				s.wg.Wait()
+
+				_, err := clientTLSConn.Read(make([]byte, 1))
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
 func TestReceiveTooBigMessage(t *testing.T) {
 	t.Parallel()
 
@@ -271,6 +622,556 @@ func TestReceiveTooBigMessage(t *testing.T) {
 	require.True(t, errors.Is(err, ErrMessageSize))
 }
 
+func TestServer_MaxConnections(t *testing.T) {
+	t.Parallel()
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand:  func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {},
+		MaxConnections: 1,
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+	defer s.Close()
+
+	go func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Serve(listener.(*net.TCPListener)))
+	}()
+
+	client := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, client.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, client))
+
+	rejected := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, rejected.Handshake())
+
+	doc := etree.NewDocument()
+	require.NoError(t, doc.ReadFromString(getMessage(t, rejected)))
+	assert.Equal(t, "2502", doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+
+	_, err := rejected.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	// Closing the first connection makes room for a new one.
+	require.NoError(t, client.Close())
+
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return len(s.activeConn) == 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	client = dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, client.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, client))
+}
+
+func TestServer_SessionLimit(t *testing.T) {
+	t.Parallel()
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {
+			data, _ := io.ReadAll(cmd)
+
+			result := NewError(StatusSuccess)
+
+			if string(data) == "logout" {
+				SessionFromContext(ctx).Logout()
+				assert.NoError(t, WriteErrorResponse(rw, "ABC-logout", "SV-1", result))
+
+				return
+			}
+
+			err := SessionFromContext(ctx).Login(string(data), "en", nil, nil)
+			if errors.Is(err, ErrSessionLimitExceeded) {
+				result = NewError(StatusSessionLimitExceededClosingConnection)
+
+				rw.CloseAfterWrite()
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, WriteErrorResponse(rw, "ABC-"+string(data), "SV-1", result))
+		},
+		SessionLimit: func(_ context.Context, clientID string) int {
+			if clientID == "ClientX" {
+				return 1
+			}
+
+			return 0
+		},
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+	defer s.Close()
+
+	go func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Serve(listener.(*net.TCPListener)))
+	}()
+
+	login := func(clientID string) (*tls.Conn, *etree.Document) {
+		client := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+		require.NoError(t, client.Handshake())
+		assert.Equal(t, "Greeting", getMessage(t, client))
+
+		buf := MessageBuffer{}
+		_, err := buf.WriteString(clientID)
+		require.NoError(t, err)
+		require.NoError(t, buf.FlushTo(client))
+
+		doc := etree.NewDocument()
+		require.NoError(t, doc.ReadFromString(getMessage(t, client)))
+
+		return client, doc
+	}
+
+	first, doc := login("ClientX")
+	assert.Equal(t, "1000", doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+
+	client, doc := login("ClientX")
+	assert.Equal(t, "2502", doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+	assert.Equal(t, "ABC-ClientX", doc.FindElement("/epp/response/trID/clTRID").Text())
+	assert.Equal(t, "SV-1", doc.FindElement("/epp/response/trID/svTRID").Text())
+
+	_, err := client.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	for range 2 {
+		_, doc = login("ClientY")
+		assert.Equal(t, "1000", doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+	}
+
+	// Logging out frees the session for another login.
+	buf := MessageBuffer{}
+	_, err = buf.WriteString("logout")
+	require.NoError(t, err)
+	require.NoError(t, buf.FlushTo(first))
+	assert.Contains(t, getMessage(t, first), "ABC-logout")
+
+	_, doc = login("ClientX")
+	assert.Equal(t, "1000", doc.FindElement("/epp/response/result").SelectAttrValue("code", ""))
+}
+
+func TestServer_AcceptPolicy(t *testing.T) {
+	t.Parallel()
+
+	var accepted []string
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {},
+		AcceptPolicy: AcceptPolicyFunc(func(conn net.Conn) error {
+			accepted = append(accepted, conn.RemoteAddr().String())
+
+			if len(accepted) > 1 {
+				return ErrConnectionNotAllowed
+			}
+
+			return nil
+		}),
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+	defer s.Close()
+
+	go func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Serve(listener.(*net.TCPListener)))
+	}()
+
+	client := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, client.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, client))
+
+	rejected := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.Error(t, rejected.Handshake())
+}
+
+func TestServer_ProxyProtocol(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu          sync.Mutex
+		remoteAddrs []string
+	)
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {},
+		ConnContext: func(ctx context.Context, conn *tls.Conn) (context.Context, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			remoteAddrs = append(remoteAddrs, conn.RemoteAddr().String())
+
+			return ctx, nil
+		},
+		ProxyProtocol: &ProxyProtocol{
+			TrustedProxies: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+			HeaderTimeout:  time.Second,
+		},
+		AcceptPolicy: &IPAcceptPolicy{
+			Allow: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		},
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+	defer s.Close()
+
+	go func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Serve(listener.(*net.TCPListener)))
+	}()
+
+	dialProxied := func(header string) *tls.Conn {
+		client := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+
+		conn := client.NetConn()
+		_, err := io.WriteString(conn, header)
+		require.NoError(t, err)
+
+		return client
+	}
+
+	// The accept policy and connection context see the client address.
+	client := dialProxied("PROXY TCP4 192.0.2.1 198.51.100.1 56324 700\r\n")
+	require.NoError(t, client.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, client))
+
+	mu.Lock()
+	assert.Equal(t, []string{"192.0.2.1:56324"}, remoteAddrs)
+	mu.Unlock()
+
+	rejected := dialProxied("PROXY TCP4 198.51.100.1 198.51.100.1 56324 700\r\n")
+	require.Error(t, rejected.Handshake())
+
+	invalid := dialProxied("GET / HTTP/1.1\r\n")
+	require.Error(t, invalid.Handshake())
+}
+
+func TestServer_ProxyProtocolShutdown(t *testing.T) {
+	t.Parallel()
+
+	s := Server{
+		Greeting:      func(ctx context.Context, rw *ResponseWriter) {},
+		HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {},
+		ProxyProtocol: &ProxyProtocol{
+			TrustedProxies: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+		},
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+
+	go func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Serve(listener.(*net.TCPListener)))
+	}()
+
+	// A proxy that never sends the header.
+	stalled := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	defer stalled.Close()
+
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return len(s.activeConn) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	forciblyClosed, err := s.Shutdown(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, forciblyClosed)
+}
+
+func TestServer_MaxPendingHandshakes(t *testing.T) {
+	t.Parallel()
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand:        func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {},
+		MaxPendingHandshakes: 1,
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+	defer s.Close()
+
+	go func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Serve(listener.(*net.TCPListener)))
+	}()
+
+	// A connection that never starts the handshake.
+	idle := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	defer idle.Close()
+
+	assert.Eventually(t, func() bool {
+		return s.pendingHandshakes.Load() == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	rejected := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.Error(t, rejected.Handshake())
+
+	// Closing the pending connection makes room for a new one.
+	require.NoError(t, idle.Close())
+
+	assert.Eventually(t, func() bool {
+		return s.pendingHandshakes.Load() == 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	client := dialServer(t, &s, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, client.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, client))
+}
+
+// deadlineErrorConn is a connection that fails to set deadlines.
+type deadlineErrorConn struct {
+	net.Conn
+}
+
+func (c deadlineErrorConn) SetReadDeadline(time.Time) error {
+	return errors.New("deadline not supported")
+}
+
+func TestServer_MaxPendingHandshakesReleasedOnError(t *testing.T) {
+	t.Parallel()
+
+	s := Server{
+		Greeting:             func(ctx context.Context, rw *ResponseWriter) {},
+		HandleCommand:        func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {},
+		MaxPendingHandshakes: 1,
+		Logger:               slog.Default(),
+		activeConn:           make(map[*eppConn]struct{}),
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	// Counted by acceptConn in Serve.
+	s.pendingHandshakes.Add(1)
+	s.wg.Add(1)
+
+	s.serveConn(deadlineErrorConn{Conn: server})
+
+	assert.Zero(t, s.pendingHandshakes.Load())
+	assert.Empty(t, s.activeConn)
+}
+
+// pipeListener is an in-memory listener with connections from net.Pipe.
+type pipeListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "pipe"}
+}
+
+func (l *pipeListener) Dial() (net.Conn, error) {
+	server, client := net.Pipe()
+
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func TestServer_PipeListener(t *testing.T) {
+	t.Parallel()
+
+	s := Server{
+		Greeting: func(ctx context.Context, rw *ResponseWriter) {
+			_, err := fmt.Fprint(rw, "Greeting")
+			assert.NoError(t, err)
+		},
+		HandleCommand: func(ctx context.Context, rw *ResponseWriter, cmd io.Reader) {
+			_, err := io.Copy(rw, cmd)
+			assert.NoError(t, err)
+		},
+		TLSConfig: tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{generateCertificate()},
+		},
+	}
+
+	listener := newPipeListener()
+	served := make(chan error)
+
+	go func() {
+		served <- s.Serve(listener)
+	}()
+
+	// Connections without keep-alive support are served.
+	conn, err := listener.Dial()
+	require.NoError(t, err)
+
+	client := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, client.Handshake())
+	assert.Equal(t, "Greeting", getMessage(t, client))
+
+	buf := MessageBuffer{}
+	_, err = buf.WriteString("Hello")
+	require.NoError(t, err)
+	require.NoError(t, buf.FlushTo(client))
+	assert.Equal(t, "Hello", getMessage(t, client))
+
+	require.NoError(t, client.Close())
+	require.NoError(t, s.Close())
+	require.NoError(t, <-served)
+}
+
+// keepAliveConn records the keep-alive settings of a connection.
+type keepAliveConn struct {
+	net.Conn
+
+	keepAlive bool
+	period    time.Duration
+	config    *net.KeepAliveConfig
+}
+
+func (c *keepAliveConn) SetKeepAlive(b bool) error {
+	c.keepAlive = b
+	return nil
+}
+
+func (c *keepAliveConn) SetKeepAlivePeriod(d time.Duration) error {
+	c.period = d
+	return nil
+}
+
+func (c *keepAliveConn) SetKeepAliveConfig(config net.KeepAliveConfig) error {
+	c.config = &config
+	return nil
+}
+
+func TestServer_KeepAlive(t *testing.T) {
+	t.Parallel()
+
+	config := &net.KeepAliveConfig{
+		Enable:   true,
+		Idle:     30 * time.Second,
+		Interval: 10 * time.Second,
+		Count:    3,
+	}
+
+	for _, tc := range []struct {
+		name            string
+		period          time.Duration
+		config          *net.KeepAliveConfig
+		expectKeepAlive bool
+		expectPeriod    time.Duration
+		expectConfig    *net.KeepAliveConfig
+	}{
+		{
+			name:            "default period",
+			expectKeepAlive: true,
+			expectPeriod:    time.Minute,
+		},
+		{
+			name:            "period",
+			period:          5 * time.Minute,
+			expectKeepAlive: true,
+			expectPeriod:    5 * time.Minute,
+		},
+		{
+			name:   "disabled",
+			period: -1,
+		},
+		{
+			name:         "config",
+			period:       5 * time.Minute,
+			config:       config,
+			expectConfig: config,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := Server{
+				KeepAlivePeriod: tc.period,
+				KeepAliveConfig: tc.config,
+				Logger:          slog.Default(),
+			}
+
+			conn := &keepAliveConn{}
+			s.setKeepAlive(conn)
+
+			assert.Equal(t, tc.expectKeepAlive, conn.keepAlive)
+			assert.Equal(t, tc.expectPeriod, conn.period)
+			assert.Equal(t, tc.expectConfig, conn.config)
+		})
+	}
+}
+
 func getMessage(t *testing.T, r io.Reader) string {
 	msgReader, err := MessageReader(r, 0)
 	require.NoError(t, err)
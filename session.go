@@ -0,0 +1,221 @@
+package epplib
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+)
+
+// Errors returned by Session.Login.
+var (
+	// ErrAlreadyLoggedIn is returned when logging in to a session that is
+	// already logged in.
+	ErrAlreadyLoggedIn = errors.New("session already logged in")
+
+	// ErrSessionLimitExceeded is returned when logging in would exceed the
+	// SessionLimit of the Server for the client id. The login should be
+	// answered with 2502 and the connection closed.
+	ErrSessionLimitExceeded = errors.New("session limit exceeded")
+)
+
+type sessionContextKey struct{}
+
+// Session holds the state of an EPP session. The Server attaches a Session to
+// the context of every connection, use SessionFromContext to get it. A Session
+// is safe for concurrent use.
+type Session struct {
+	remoteAddr net.Addr
+	connState  tls.ConnectionState
+
+	// mu guards the fields below.
+	mu       sync.RWMutex
+	loggedIn bool
+	clientID string
+	lang     string
+	objURIs  Namespaces
+	extURIs  Namespaces
+
+	failedLogins int
+
+	// onLogin is called before the session is logged in and can refuse the
+	// login, onLogout is called when a logged in session is logged out. They
+	// are set by the Server to enforce the SessionLimit.
+	onLogin  func(clientID string) error
+	onLogout func(clientID string)
+}
+
+// ContextWithSession returns a copy of ctx with the session attached.
+func ContextWithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// SessionFromContext returns the session attached to ctx or nil if there is
+// none.
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionContextKey{}).(*Session)
+	return session
+}
+
+// newSession creates a session for a connection that has completed the TLS
+// handshake.
+func newSession(conn *tls.Conn) *Session {
+	return &Session{
+		remoteAddr: conn.RemoteAddr(),
+		connState:  conn.ConnectionState(),
+	}
+}
+
+// RemoteAddr returns the remote address of the connection.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.remoteAddr
+}
+
+// ConnectionState returns the TLS state of the connection.
+func (s *Session) ConnectionState() tls.ConnectionState {
+	return s.connState
+}
+
+// Login marks the session as logged in with the client id, language and the
+// object and extension namespaces from the login services.
+// ErrSessionLimitExceeded is returned, and the session isn't logged in, if the
+// login would exceed the SessionLimit of the Server.
+func (s *Session) Login(clientID, lang string, objURIs, extURIs Namespaces) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loggedIn {
+		return ErrAlreadyLoggedIn
+	}
+
+	if s.onLogin != nil {
+		if err := s.onLogin(clientID); err != nil {
+			return err
+		}
+	}
+
+	s.loggedIn = true
+	s.clientID = clientID
+	s.lang = lang
+	s.objURIs = objURIs
+	s.extURIs = extURIs
+
+	return nil
+}
+
+// LoginNegotiated logs in like Login with the client id and language of the
+// login command and the object and extension namespaces negotiated from its
+// svcs against the namespaces supported by the server, see
+// NegotiateNamespaces. The chosen versions are available from
+// NegotiatedNamespace.
+func (s *Session) LoginNegotiated(login *Login, supported Namespaces) error {
+	var objects, extensions Namespaces
+
+	for _, ns := range supported {
+		switch {
+		case ns.IsObjectNamespace():
+			objects = append(objects, ns)
+		case ns.IsExtensionNamespace():
+			extensions = append(extensions, ns)
+		}
+	}
+
+	return s.Login(login.ClientID, login.Lang,
+		NegotiateNamespaces(login.ObjURIs, objects),
+		NegotiateNamespaces(login.ExtURIs, extensions),
+	)
+}
+
+// loginFailed records a failed login and returns the number of failed logins.
+func (s *Session) loginFailed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failedLogins++
+
+	return s.failedLogins
+}
+
+// Logout marks the session as logged out.
+func (s *Session) Logout() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loggedIn && s.onLogout != nil {
+		s.onLogout(s.clientID)
+	}
+
+	s.loggedIn = false
+	s.clientID = ""
+	s.lang = ""
+	s.objURIs = nil
+	s.extURIs = nil
+}
+
+// LoggedIn reports if the session is logged in.
+func (s *Session) LoggedIn() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.loggedIn
+}
+
+// ClientID returns the client id used to log in.
+func (s *Session) ClientID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.clientID
+}
+
+// Lang returns the language chosen at login, defaults to "en".
+func (s *Session) Lang() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.lang == "" {
+		return "en"
+	}
+
+	return s.lang
+}
+
+// ObjectNamespaces returns the object namespaces chosen at login.
+func (s *Session) ObjectNamespaces() Namespaces {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.objURIs
+}
+
+// ExtensionNamespaces returns the extension namespaces chosen at login.
+func (s *Session) ExtensionNamespaces() Namespaces {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.extURIs
+}
+
+// NegotiatedNamespace returns the namespace of the same family as ns chosen at
+// login, e.g. NamespaceIETFSecDNS10 for NamespaceIETFSecDNS11 if the client
+// logged in with secDNS-1.0 only. False is returned if no namespace of the
+// family was chosen.
+func (s *Session) NegotiatedNamespace(ns Namespace) (Namespace, bool) {
+	info, ok := ns.Info()
+	if !ok {
+		return NamespaceUnknown, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, chosen := range slices.Concat(s.objURIs, s.extURIs) {
+		if chosenInfo, _ := chosen.Info(); chosenInfo.Family == info.Family {
+			return chosen, true
+		}
+	}
+
+	return NamespaceUnknown, false
+}
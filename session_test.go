@@ -0,0 +1,71 @@
+package epplib
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	session := &Session{}
+	ctx := ContextWithSession(context.Background(), session)
+
+	assert.Same(t, session, SessionFromContext(ctx))
+	assert.Nil(t, SessionFromContext(context.Background()))
+
+	assert.False(t, session.LoggedIn())
+	assert.Equal(t, "en", session.Lang())
+
+	err := session.Login("ClientX", "sv",
+		Namespaces{NamespaceIETFDomain10},
+		Namespaces{NamespaceIETFSecDNS11},
+	)
+	require.NoError(t, err)
+
+	assert.True(t, session.LoggedIn())
+	assert.Equal(t, "ClientX", session.ClientID())
+	assert.Equal(t, "sv", session.Lang())
+	assert.Equal(t, Namespaces{NamespaceIETFDomain10}, session.ObjectNamespaces())
+	assert.Equal(t, Namespaces{NamespaceIETFSecDNS11}, session.ExtensionNamespaces())
+
+	err = session.Login("ClientY", "en", nil, nil)
+	require.ErrorIs(t, err, ErrAlreadyLoggedIn)
+	assert.Equal(t, "ClientX", session.ClientID())
+
+	session.Logout()
+
+	assert.False(t, session.LoggedIn())
+	assert.Equal(t, "", session.ClientID())
+	assert.Empty(t, session.ObjectNamespaces())
+}
+
+func TestSession_NegotiatedNamespace(t *testing.T) {
+	t.Parallel()
+
+	session := &Session{}
+	require.NoError(t, session.LoginNegotiated(&Login{
+		ClientID: "ClientX",
+		Lang:     "en",
+		ObjURIs:  []string{"urn:ietf:params:xml:ns:domain-1.0", "urn:ietf:params:xml:ns:contact-1.0"},
+		ExtURIs:  []string{"urn:ietf:params:xml:ns:secDNS-1.0"},
+	}, Namespaces{NamespaceIETFDomain10, NamespaceIETFSecDNS10, NamespaceIETFSecDNS11}))
+
+	assert.Equal(t, "ClientX", session.ClientID())
+	assert.Equal(t, Namespaces{NamespaceIETFDomain10}, session.ObjectNamespaces())
+	assert.Equal(t, Namespaces{NamespaceIETFSecDNS10}, session.ExtensionNamespaces())
+
+	ns, ok := session.NegotiatedNamespace(NamespaceIETFSecDNS11)
+	assert.True(t, ok)
+	assert.Equal(t, NamespaceIETFSecDNS10, ns)
+
+	ns, ok = session.NegotiatedNamespace(NamespaceIETFDomain10)
+	assert.True(t, ok)
+	assert.Equal(t, NamespaceIETFDomain10, ns)
+
+	_, ok = session.NegotiatedNamespace(NamespaceIISEpp12)
+	assert.False(t, ok)
+}
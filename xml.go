@@ -37,3 +37,13 @@ func ParseXMLBool(value string) (bool, error) {
 		return false, fmt.Errorf("invalid value: %s", value)
 	}
 }
+
+// FormatXMLBool formats a boolean in the canonical representation of the
+// XML Schema Part 2: Datatypes 3.2.2 boolean specification.
+func FormatXMLBool(value bool) string {
+	if value {
+		return "true"
+	}
+
+	return "false"
+}